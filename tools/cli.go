@@ -0,0 +1,611 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"main/model"
+	"main/pdf"
+	"main/raster"
+	"main/read_write"
+	"main/svg"
+	"main/tikz"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+// exit codes
+const (
+	exitOK      = 0
+	exitError   = 1 // the command failed, e.g. a file could not be read or written
+	exitUsage   = 2 // the command line could not be parsed
+	exitInvalid = 3 // the input has errors
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"edit", "open a layout in the editor", runEdit},
+		{"export", "export a saved layout to PDF, SVG, PNG, TIFF or TikZ", runExport},
+		{"validate", "check the parameter table and saved layout for problems", runValidate},
+		{"layout", "arrange a layout automatically and save it", runLayout},
+		{"list", "list the saved layouts in a directory", runList},
+		{"info", "print a summary of a saved layout", runInfo},
+	}
+}
+
+// legacyActions are the actions of the positional form "base_dir layout_name action [args]" used by the R package
+var legacyActions = []string{"edit", "export", "export-svg", "export-raster", "export-tikz"}
+
+// RunCLI runs the command given by args and returns the exit code
+func RunCLI(args []string) int {
+	if len(args) >= 3 && !isCommand(args[0]) && slices.Contains(legacyActions, args[2]) {
+		args = translateLegacy(args)
+	}
+
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 && isCommand(args[1]) {
+			return runCommand(args[1], []string{"-h"})
+		}
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	if !isCommand(args[0]) {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+
+	return runCommand(args[0], args[1:])
+}
+
+func isCommand(name string) bool {
+	return slices.ContainsFunc(commands, func(c command) bool { return c.name == name })
+}
+
+// runCommand runs a command, turning panics from deeper in the program into an error exit code
+func runCommand(name string, args []string) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, r)
+			code = exitError
+		}
+	}()
+
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(args)
+		}
+	}
+	return exitUsage
+}
+
+// translateLegacy maps "base_dir layout_name action [args]" onto the equivalent subcommand
+func translateLegacy(args []string) []string {
+	baseDir, layoutName, action, rest := args[0], args[1], args[2], args[3:]
+	common := []string{"-dir", baseDir, "-layout", layoutName}
+
+	switch action {
+	case "edit":
+		res := append([]string{"edit"}, common...)
+		if len(rest) > 0 {
+			res = append(res, "-initial-layout", rest[0])
+		}
+		if len(rest) > 1 {
+			res = append(res, "-solution", rest[1])
+		}
+		return res
+	case "export", "export-svg", "export-raster", "export-tikz":
+		format := map[string]string{
+			"export":        "pdf",
+			"export-svg":    "svg",
+			"export-raster": "png",
+			"export-tikz":   "tikz",
+		}[action]
+
+		res := append([]string{"export"}, common...)
+		if len(rest) > 0 {
+			res = append(res, "-o", rest[0])
+			if action == "export-raster" {
+				// keep the file type of the given path
+				format = ""
+			}
+		}
+		if format != "" {
+			res = append(res, "-format", format)
+		}
+		if len(rest) > 1 {
+			switch action {
+			case "export-raster":
+				res = append(res, "-dpi", rest[1])
+			case "export-tikz":
+				res = append(res, "-standalone="+fmt.Sprint(rest[1] == "standalone"))
+			}
+		}
+		return res
+	}
+
+	return args
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: sem_gui <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `run "sem_gui help <command>" for the flags of a command`)
+	fmt.Fprintln(w, `the form "sem_gui base_dir layout_name edit|export path" is still accepted`)
+}
+
+// newFlagSet creates the flags shared by all commands
+func newFlagSet(name, usage string) (fs *flag.FlagSet, dir *string) {
+	fs = flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: sem_gui %s %s\n\n", name, usage)
+		fs.PrintDefaults()
+	}
+	dir = fs.String("dir", ".", "base directory holding temp.json and the saved layouts")
+	return fs, dir
+}
+
+// parseFlags parses args, returning an exit code if the command should stop
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	err := fs.Parse(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK, false
+	case err != nil:
+		return exitUsage, false
+	case fs.NArg() > 0:
+		fmt.Fprintf(fs.Output(), "unexpected argument %q\n", fs.Arg(0))
+		fs.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+func requireLayout(fs *flag.FlagSet, layoutName string) bool {
+	if layoutName == "" {
+		fmt.Fprintln(fs.Output(), "missing -layout")
+		fs.Usage()
+		return false
+	}
+	return true
+}
+
+func layoutPath(dir, layoutName string) string {
+	return filepath.Join(dir, layoutName+".json")
+}
+
+// inputFlags are the flags of the commands that read a model
+type inputFlags struct {
+	input        *string
+	standardized *bool
+	columns      *string
+	latent       *string
+}
+
+func addInputFlags(fs *flag.FlagSet) inputFlags {
+	return inputFlags{
+		input: fs.String("input", "", "model to draw: a parameter table or OpenMx RAM model (.json), a parameter table (.csv, .tsv), "+
+			"lavaan model syntax (.lav, .lavaan) or Mplus output (.out). Defaults to temp.json in the base directory"),
+		standardized: fs.Bool("standardized", false, "draw the STDYX standardized results of Mplus output"),
+		columns:      fs.String("columns", "", "columns of a CSV parameter table without their usual names, e.g. est=Estimate,pvalue=Pr"),
+		latent: fs.String("latent", "", "latent variables of a CSV parameter table that writes loadings as regressions of the "+
+			"indicators on them, as semopy does, e.g. f1,f2"),
+	}
+}
+
+func addSolutionFlag(fs *flag.FlagSet) *string {
+	return fs.String("solution", "", "solution to show the estimates in: unstandardized, standardized, both, unstandardized-se or "+
+		"standardized-se. Saved layouts keep theirs if empty")
+}
+
+// checkSolution reports a solution flag that names no solution
+func checkSolution(name string) (int, bool) {
+	if name == "" {
+		return exitOK, true
+	}
+	if _, err := model.ParseSolution(name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// applySolution shows the estimates of the model in the named solution. A solution the input has no estimates for is
+// reported and left out
+func applySolution(m *model.Model, name string) {
+	if name == "" {
+		return
+	}
+	s, _ := model.ParseSolution(name) // checked with the other flags
+	if !slices.Contains(m.Solutions(), s) {
+		fmt.Fprintf(os.Stderr, "the input has no estimates for the %s solution\n", s)
+		return
+	}
+	m.Solution = s
+}
+
+// checkRows prints the problems found in the rows and reports whether they can be drawn
+func checkRows(rows []read_write.DataRow) bool {
+	diags := read_write.ValidateRows(rows)
+	for _, d := range diags {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	return !read_write.HasErrors(diags)
+}
+
+func (f inputFlags) read(dir string) ([]read_write.DataRow, error) {
+	columns, err := read_write.ParseColumnMapping(*f.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var latent []string
+	for _, name := range strings.Split(*f.latent, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			latent = append(latent, name)
+		}
+	}
+
+	return read_write.ReadInput(f.path(dir), read_write.InputOptions{Standardized: *f.standardized, Columns: columns, Latent: latent})
+}
+
+// fitMeasures reads the fit indices given with the model, if any
+func (f inputFlags) fitMeasures(dir string) (model.FitMeasures, error) {
+	return read_write.ReadFitMeasures(f.path(dir))
+}
+
+func (f inputFlags) path(dir string) string {
+	if *f.input == "" {
+		return filepath.Join(dir, "temp.json")
+	}
+	return *f.input
+}
+
+func loadLayout(dir, layoutName string) (*model.Model, error) {
+	m, err := read_write.LoadProject(layoutPath(dir, layoutName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("layout %q not found in %s", layoutName, dir)
+	}
+	return m, err
+}
+
+func runEdit(args []string) int {
+	fs, dir := newFlagSet("edit", "-layout name [-dir path] [-input path [-standardized] [-columns mapping] [-latent names]] [-initial-layout layered|layered-vertical|force] [-solution name]")
+	layoutName := fs.String("layout", "", "name of the layout to edit (created if it does not exist)")
+	input := addInputFlags(fs)
+	initial := fs.String("initial-layout", "layered", "arrangement of a new layout: layered, layered-vertical or force")
+	solution := addSolutionFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !requireLayout(fs, *layoutName) {
+		return exitUsage
+	}
+
+	initialLayout, err := read_write.ParseInitialLayout(*initial)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if code, ok := checkSolution(*solution); !ok {
+		return code
+	}
+
+	rows, err := input.read(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if !checkRows(rows) {
+		return exitInvalid
+	}
+
+	fit, err := input.fitMeasures(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	m := read_write.ModelFromRows(rows, *dir, *layoutName, initialLayout)
+	m.Fit = fit
+	applySolution(m, *solution)
+	Edit(m, *dir, *layoutName)
+	return exitOK
+}
+
+func runExport(args []string) int {
+	fs, dir := newFlagSet("export", "-layout name -o path [-dir path] [-format pdf|svg|png|tiff|tikz] [-dpi n] [-standalone] [-solution name] [-template text] [-precision n]")
+	layoutName := fs.String("layout", "", "name of the saved layout to export")
+	out := fs.String("o", "", "output file")
+	format := fs.String("format", "", "output format, inferred from the output file extension if empty")
+	dpi := fs.Float64("dpi", 300, "resolution of PNG and TIFF exports")
+	standalone := fs.Bool("standalone", false, "wrap TikZ exports in a compilable standalone document")
+	template := fs.String("template", "", "template of the estimate labels, e.g. \"{est}{stars} [{lo}, {hi}]\" or \"{est} ({se})\". "+
+		"The saved one is used if empty")
+	precision := fs.Int("precision", -1, fmt.Sprintf("decimal places of the estimates, from 0 to %d. The saved number is used if negative", model.MaxPrecision))
+	solution := addSolutionFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !requireLayout(fs, *layoutName) {
+		return exitUsage
+	}
+	if *out == "" {
+		fmt.Fprintln(fs.Output(), "missing -o")
+		fs.Usage()
+		return exitUsage
+	}
+	if *dpi <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid dpi: %v\n", *dpi)
+		return exitUsage
+	}
+	if err := model.ParseLabelTemplate(*template); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *precision < -1 || *precision > model.MaxPrecision {
+		fmt.Fprintf(os.Stderr, "invalid precision: %d (expected 0 to %d)\n", *precision, model.MaxPrecision)
+		return exitUsage
+	}
+	if code, ok := checkSolution(*solution); !ok {
+		return code
+	}
+
+	f := strings.ToLower(*format)
+	if f == "" {
+		f = formatFromPath(*out)
+	}
+
+	m, err := loadLayout(*dir, *layoutName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if *solution != "" || *template != "" || *precision >= 0 {
+		// only this export is affected, the saved layout keeps its labels
+		applySolution(m, *solution)
+		if *template == "" {
+			*template = m.LabelTemplate
+		}
+		m.SetLabelFormat(*template, *precision)
+		// measure the new labels at the scale the layout was saved in, as the editor would
+		model.CalculateModel(m, layout.Context{Metric: unit.Metric{PxPerDp: m.PxPerDp, PxPerSp: m.PxPerDp}})
+	}
+
+	switch f {
+	case "pdf":
+		pdf.ExportModel(m, *out)
+		fmt.Println("Successfully exported PDF")
+	case "svg":
+		svg.ExportModel(m, *out)
+		fmt.Println("Successfully exported SVG")
+	case "png", "tif", "tiff":
+		// the raster exporter picks the encoding from the file extension
+		raster.ExportModel(m, *out, *dpi)
+		fmt.Println("Successfully exported raster image")
+	case "tikz", "tex":
+		tikz.ExportModel(m, *out, *standalone)
+		fmt.Println("Successfully exported TikZ")
+	default:
+		fmt.Fprintf(os.Stderr, "unknown export format %q (expected pdf, svg, png, tiff or tikz)\n", f)
+		return exitUsage
+	}
+
+	return exitOK
+}
+
+func formatFromPath(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".tex":
+		return "tikz"
+	case "":
+		return "pdf"
+	default:
+		return ext[1:]
+	}
+}
+
+func runValidate(args []string) int {
+	fs, dir := newFlagSet("validate", "[-dir path] [-input path [-standardized] [-columns mapping] [-latent names]] [-layout name]")
+	layoutName := fs.String("layout", "", "also check the saved layout with this name")
+	input := addInputFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	var diags []read_write.Diagnostic
+	rows, err := input.read(*dir)
+	if err != nil {
+		diags = append(diags, read_write.Diagnostic{Severity: read_write.SEVERITY_ERROR, Message: err.Error()})
+	} else {
+		diags = read_write.ValidateRows(rows)
+	}
+
+	if *layoutName != "" {
+		m, err := loadLayout(*dir, *layoutName)
+		if err == nil {
+			err = model.ParseLabelTemplate(m.LabelTemplate)
+		}
+		if err != nil {
+			diags = append(diags, read_write.Diagnostic{Severity: read_write.SEVERITY_ERROR, Message: err.Error()})
+		}
+	}
+
+	errCount := 0
+	for _, d := range diags {
+		fmt.Println(d)
+		if d.Severity == read_write.SEVERITY_ERROR {
+			errCount++
+		}
+	}
+
+	if errCount > 0 {
+		fmt.Printf("invalid: %d errors and %d warnings\n", errCount, len(diags)-errCount)
+		return exitInvalid
+	}
+	fmt.Printf("ok: %d rows and %d warnings\n", len(rows), len(diags))
+	return exitOK
+}
+
+func runLayout(args []string) int {
+	fs, dir := newFlagSet("layout", "-layout name [-dir path] [-input path [-standardized] [-columns mapping] [-latent names]] [-algorithm layered|layered-vertical|force] [-solution name]")
+	layoutName := fs.String("layout", "", "name of the layout to arrange. Saved positions are replaced")
+	input := addInputFlags(fs)
+	algorithm := fs.String("algorithm", "layered", "layout algorithm: layered, layered-vertical or force")
+	solution := addSolutionFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !requireLayout(fs, *layoutName) {
+		return exitUsage
+	}
+
+	initialLayout, err := read_write.ParseInitialLayout(*algorithm)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if code, ok := checkSolution(*solution); !ok {
+		return code
+	}
+
+	rows, err := input.read(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if !checkRows(rows) {
+		return exitInvalid
+	}
+
+	fit, err := input.fitMeasures(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	_, statErr := os.Stat(layoutPath(*dir, *layoutName))
+	m := read_write.ModelFromRows(rows, *dir, *layoutName, initialLayout)
+	m.Fit = fit
+	applySolution(m, *solution)
+	if statErr == nil {
+		// an existing layout keeps its positions when loaded, so arrange it explicitly
+		read_write.ArrangeModel(m, initialLayout)
+	}
+
+	// without a window the layout is calculated as on a screen with one pixel per dp
+	if m.PxPerDp == 0 {
+		m.PxPerDp = 1
+	}
+	model.CalculateModel(m, layout.Context{})
+	read_write.SaveProject(m, layoutPath(*dir, *layoutName))
+
+	fmt.Printf("arranged %d nodes in %s\n", len(m.Nodes), layoutPath(*dir, *layoutName))
+	return exitOK
+}
+
+func runList(args []string) int {
+	fs, dir := newFlagSet("list", "[-dir path]")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	for _, p := range paths {
+		if !read_write.IsProject(p) {
+			continue
+		}
+		fmt.Println(strings.TrimSuffix(filepath.Base(p), ".json"))
+	}
+	return exitOK
+}
+
+func runInfo(args []string) int {
+	fs, dir := newFlagSet("info", "-layout name [-dir path]")
+	layoutName := fs.String("layout", "", "name of the saved layout")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !requireLayout(fs, *layoutName) {
+		return exitUsage
+	}
+
+	m, err := loadLayout(*dir, *layoutName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	var observed, latent, composites, intercepts, hidden int
+	for _, n := range m.Nodes {
+		switch {
+		case !n.Visible:
+			hidden++
+		case n.Class == model.OBSERVED:
+			observed++
+		case n.Class == model.LATENT:
+			latent++
+		case n.Class == model.COMPOSITE:
+			composites++
+		case n.Class == model.INTERCEPT:
+			intercepts++
+		}
+	}
+
+	var paths, covariances, variances int
+	for _, c := range m.Connections {
+		switch c.Type {
+		case model.STRAIGHT:
+			paths++
+		case model.CURVED:
+			covariances++
+		case model.CIRCULAR:
+			variances++
+		}
+	}
+
+	fmt.Printf("layout:      %s\n", layoutPath(*dir, *layoutName))
+	fmt.Printf("nodes:       %d observed, %d latent, %d composites, %d intercepts, %d hidden\n", observed, latent, composites, intercepts, hidden)
+	fmt.Printf("connections: %d paths, %d covariances, %d variances\n", paths, covariances, variances)
+	if m.IsMultiGroup() {
+		fmt.Printf("groups:      %v (showing %s)\n", m.Groups, m.GroupTitle(m.ActiveGroup))
+	}
+	fmt.Printf("estimates:   %s, %s\n", m.CoeffDisplay, m.Solution)
+	fmt.Printf("font:        %s %.0f\n", m.Font.Family, m.Font.Size)
+	if len(m.Nodes) > 0 && m.PxPerDp != 0 {
+		_, dim := model.GetModelSize(model.PrepareForExport(m))
+		fmt.Printf("size:        %.0f x %.0f pt\n", dim.W*.75, dim.H*.75)
+	}
+	return exitOK
+}
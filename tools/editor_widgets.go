@@ -1,11 +1,17 @@
 package main
 
 import (
+	"image"
+	"image/color"
 	"main/model"
 	"main/utils"
+	"slices"
+	"strconv"
 
+	"gioui.org/io/key"
 	"gioui.org/layout"
 	"gioui.org/op"
+	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 )
@@ -15,9 +21,24 @@ const (
 	EDITOR_HEIGHT = 40
 )
 
+var toolbarCol = color.NRGBA{R: 60, G: 64, B: 72, A: 230}
+
 type ModelWidgets struct {
 	nodeWidgets       map[*model.Node]*NodeWidget
 	connectionWidgets map[*model.Connection]*ConnectionWidget
+	toolbar           []*DisplayControl
+}
+
+// DisplayControl is a button of the toolbar that shows the state of a display option and switches to the next one. The
+// shortcut switches it as well
+type DisplayControl struct {
+	name     string
+	shortcut key.Name
+	shift    bool
+	button   widget.Clickable
+	state    func(m *model.Model) string
+	applies  func(m *model.Model) bool // whether the model has anything for the option to show
+	next     func(m *model.Model)
 }
 
 type NodeWidget struct {
@@ -44,9 +65,154 @@ func InitWidgets(m *model.Model) ModelWidgets {
 		w.nodeWidgets[n] = new(NodeWidget)
 	}
 
+	w.toolbar = newToolbar()
+
 	return w
 }
 
+func newToolbar() []*DisplayControl {
+	always := func(m *model.Model) bool { return true }
+	multiGroup := func(m *model.Model) bool { return len(m.Groups) > 1 }
+	onOff := func(on bool, yes, no string) string {
+		if on {
+			return yes
+		}
+		return no
+	}
+
+	return []*DisplayControl{
+		{
+			name: "Estimates", shortcut: "K",
+			state:   func(m *model.Model) string { return m.CoeffDisplay.String() },
+			applies: always,
+			next:    (*model.Model).NextCoeffDisplay,
+		},
+		{
+			name: "Template", shortcut: "K", shift: true,
+			state:   func(m *model.Model) string { return onOff(m.LabelTemplate != "", m.LabelTemplate, "none") },
+			applies: always,
+			next:    (*model.Model).NextLabelTemplate,
+		},
+		{
+			name: "Decimals", shortcut: "P",
+			state:   func(m *model.Model) string { return strconv.Itoa(m.EstimatePrecision()) },
+			applies: always,
+			next:    (*model.Model).NextPrecision,
+		},
+		{
+			name: "Solution", shortcut: "U",
+			state:   func(m *model.Model) string { return m.Solution.String() },
+			applies: func(m *model.Model) bool { return len(m.Solutions()) > 1 },
+			next:    (*model.Model).NextSolution,
+		},
+		{
+			name: "Parameter labels", shortcut: "L",
+			state:   func(m *model.Model) string { return m.LabelDisplay.String() },
+			applies: hasLabels,
+			next:    (*model.Model).NextLabelDisplay,
+		},
+		{
+			name: "Equality constraints", shortcut: "L", shift: true,
+			state:   func(m *model.Model) string { return onOff(m.HighlightConstraints, "highlighted", "not highlighted") },
+			applies: hasLabels,
+			next:    func(m *model.Model) { m.HighlightConstraints = !m.HighlightConstraints },
+		},
+		{
+			name: "Thresholds", shortcut: "T",
+			state: func(m *model.Model) string { return m.ThresholdDisplay.String() },
+			applies: func(m *model.Model) bool {
+				return slices.ContainsFunc(m.Nodes, func(n *model.Node) bool { return len(n.Thresholds) > 0 })
+			},
+			next: (*model.Model).NextThresholdDisplay,
+		},
+		{
+			name: "R²", shortcut: "R",
+			state:   func(m *model.Model) string { return m.R2Display.String() },
+			applies: func(m *model.Model) bool { return slices.ContainsFunc(m.Nodes, (*model.Node).HasR2) },
+			next:    (*model.Model).NextR2Display,
+		},
+		{
+			name: "Defined parameters", shortcut: "I",
+			state:   func(m *model.Model) string { return m.EffectsDisplay.String() },
+			applies: func(m *model.Model) bool { return len(m.DefinedParams) > 0 },
+			next:    (*model.Model).NextEffectsDisplay,
+		},
+		{
+			name: "Fit indices", shortcut: "F",
+			state:   func(m *model.Model) string { return onOff(m.ShowFit, "shown", "hidden") },
+			applies: func(m *model.Model) bool { return len(m.Fit) > 0 },
+			next:    (*model.Model).ToggleFit,
+		},
+		{
+			name: "Group", shortcut: "G",
+			state:   func(m *model.Model) string { return strconv.Itoa(m.ActiveGroup) },
+			applies: multiGroup,
+			next:    (*model.Model).NextGroup,
+		},
+		{
+			name: "Export groups", shortcut: "G", shift: true,
+			state:   func(m *model.Model) string { return onOff(m.GroupPanels, "side by side", "separately") },
+			applies: multiGroup,
+			next:    func(m *model.Model) { m.GroupPanels = !m.GroupPanels },
+		},
+	}
+}
+
+func hasLabels(m *model.Model) bool {
+	return slices.ContainsFunc(m.Connections, func(c *model.Connection) bool { return c.Label != "" })
+}
+
+// Shortcut returns the display option switched with ctrl and the key, if the model has anything for it to show
+func (w ModelWidgets) Shortcut(m *model.Model, name key.Name, shift bool) *DisplayControl {
+	for _, c := range w.toolbar {
+		if c.shortcut == name && c.shift == shift && c.applies(m) {
+			return c
+		}
+	}
+	return nil
+}
+
+// UpdateToolbar switches the display options whose buttons were clicked. It runs before the model is calculated, so
+// that the frame already shows the change
+func (w ModelWidgets) UpdateToolbar(gtx layout.Context, m *model.Model, ec *EditContext) {
+	for _, c := range w.toolbar {
+		for c.button.Clicked(gtx) {
+			if c.applies(m) {
+				c.next(m)
+				ec.lazyUpdate = false
+			}
+		}
+	}
+}
+
+// DrawToolbar draws a button for every display option that applies to the model in the top left corner of the window,
+// labelled with the current state of the option
+func (w ModelWidgets) DrawToolbar(gtx layout.Context, th *material.Theme, m *model.Model, ec *EditContext) {
+	var children []layout.FlexChild
+	for _, c := range w.toolbar {
+		if !c.applies(m) {
+			continue
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				btn := material.Button(th, &c.button, c.name+": "+c.state(m))
+				btn.Background = toolbarCol
+				btn.TextSize = unit.Sp(12)
+				btn.Inset = layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4), Left: unit.Dp(8), Right: unit.Dp(8)}
+				return btn.Layout(gtx)
+			})
+		}))
+	}
+
+	gtx.Constraints.Min = image.Point{}
+	inset := layout.UniformInset(unit.Dp(8))
+	dims := inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+	// clicks on the toolbar are not meant for the canvas below it
+	ec.toolbarRect = image.Rectangle{Max: dims.Size}
+}
+
 func (w ModelWidgets) DrawNodeEditor(ops *op.Ops, gtx layout.Context, th *material.Theme, n *model.Node, pos utils.LocalPos, ec *EditContext) {
 	//nodeWidget := w.nodeWidgets[n]
 	//
@@ -2,14 +2,16 @@ module main
 
 go 1.25.0
 
-require gioui.org v0.9.0
+require (
+	gioui.org v0.9.0
+	github.com/jung-kurt/gofpdf v1.16.2
+	golang.org/x/image v0.26.0
+)
 
 require (
 	gioui.org/shader v1.0.8 // indirect
 	github.com/go-text/typesetting v0.3.0 // indirect
-	github.com/jung-kurt/gofpdf v1.16.2 // indirect
 	golang.org/x/exp/shiny v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
-	golang.org/x/image v0.26.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
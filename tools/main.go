@@ -1,15 +1,16 @@
 package main
 
 import (
-	"fmt"
 	"image"
+	"image/color"
 	"log"
 	"main/model"
-	"main/pdf"
 	"main/read_write"
 	"main/utils"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
@@ -17,6 +18,7 @@ import (
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
+	"gioui.org/text"
 	"gioui.org/unit"
 	"gioui.org/widget/material"
 )
@@ -29,9 +31,9 @@ const (
 
 var (
 	leftClickTag  = new(int)
+	selectionCol  = color.NRGBA{R: 66, G: 133, B: 244, A: 60}
 	rightClickTag = new(int)
 	ctrlPressTag  = new(int)
-	decimalPlaces int
 )
 
 // EditContext contains the current editor state
@@ -45,34 +47,31 @@ type EditContext struct {
 	panOffset         utils.LocalPos
 	draggedNode       *model.Node
 	draggedConnection *model.Connection
+	draggedEffects    bool // whether the block of defined parameters is being dragged
+	draggedFit        bool // whether the box of fit indices is being dragged
+	selectedNodes     map[*model.Node]bool
+	selecting         bool // whether a rubber-band selection is in progress
+	selectStart       utils.GlobalPos
+	selectEnd         utils.GlobalPos
+	selectBase        map[*model.Node]bool // the selection before the rubber-band started
 	editingSelection  interface{}
 	lazyUpdate        bool
+	history           model.History
+	dragRecorded      bool            // whether the current drag has been added to the history
+	toolbarRect       image.Rectangle // area of the toolbar in window coordinates
+	toolbarPressed    bool            // whether the current press started on the toolbar
 }
 
 func main() {
-	// load in arguments passed into the application call
-	baseDir := os.Args[1]
-	projectName := os.Args[2]
-	action := os.Args[3]
-
-	if action == "export" {
-		fp := os.Args[4]
-		m, err := read_write.LoadProject(filepath.Join(baseDir, projectName+".json"))
-		if err != nil {
-			log.Fatal(err)
-		}
-		pdf.ExportModel(m, fp)
-		fmt.Println("Successfully exported PDF")
-		return
-	}
+	os.Exit(RunCLI(os.Args[1:]))
+}
 
-	m := read_write.ModelFromJSON(baseDir, projectName)
+// Edit opens the model in the editor, saving it as projectName in baseDir. It only returns by exiting the program
+func Edit(m *model.Model, baseDir, projectName string) {
 	ec := InitEditContext()
 	widgets := InitWidgets(m)
 	th := material.NewTheme()
-
-	// testing
-	decimalPlaces = 2 //todo: allow changing of decimal places
+	th.Shaper = text.NewShaper(text.NoSystemFonts(), text.WithCollection(utils.LoadSansFontFace()))
 
 	go func() {
 		// create new window
@@ -129,13 +128,16 @@ func loop(w *app.Window, th *material.Theme, m *model.Model, ec *EditContext, wi
 
 			RightClick(ops, gtx, m, ec, widgets)
 
-			CtrlPress(ops, gtx, m, baseDir, projectName)
+			CtrlPress(ops, gtx, m, ec, widgets, baseDir, projectName)
+
+			widgets.UpdateToolbar(gtx, m, ec)
 
 			// draw the model
 			if !ec.lazyUpdate {
 				model.CalculateModel(m, gtx)
 			}
 			DrawModel(ops, gtx, m, ec)
+			widgets.DrawToolbar(gtx, th, m, ec)
 
 			// complete the frame event
 			e.Frame(gtx.Ops)
@@ -147,12 +149,13 @@ func loop(w *app.Window, th *material.Theme, m *model.Model, ec *EditContext, wi
 	}
 }
 
-func CtrlPress(ops *op.Ops, gtx layout.Context, m *model.Model, baseDir, projectName string) {
+func CtrlPress(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext, widgets ModelWidgets, baseDir, projectName string) {
 	event.Op(ops, ctrlPressTag)
 
 	for {
 		ev, ok := gtx.Event(key.Filter{
 			Required: key.ModCtrl,
+			Optional: key.ModShift,
 		})
 		if !ok {
 			break
@@ -163,15 +166,63 @@ func CtrlPress(ops *op.Ops, gtx layout.Context, m *model.Model, baseDir, project
 			if evt.State != key.Press {
 				break
 			}
+			// the display options are switched like their buttons on the toolbar
+			if c := widgets.Shortcut(m, evt.Name, evt.Modifiers.Contain(key.ModShift)); c != nil {
+				c.next(m)
+				ec.lazyUpdate = false
+				break
+			}
+
 			switch evt.Name {
 			case "S":
 				read_write.SaveProject(m, filepath.Join(baseDir, projectName+".json"))
 				println("successfully saved project")
+			case key.NameLeftArrow:
+				AlignSelection(m, ec, model.ALIGN_LEFT)
+			case key.NameRightArrow:
+				AlignSelection(m, ec, model.ALIGN_RIGHT)
+			case key.NameUpArrow:
+				AlignSelection(m, ec, model.ALIGN_TOP)
+			case key.NameDownArrow:
+				AlignSelection(m, ec, model.ALIGN_BOTTOM)
+			case "E":
+				if evt.Modifiers.Contain(key.ModShift) {
+					AlignSelection(m, ec, model.ALIGN_CENTER_Y)
+				} else {
+					AlignSelection(m, ec, model.ALIGN_CENTER_X)
+				}
+			case "D":
+				if len(ec.selectedNodes) > 2 {
+					ec.history.Record(m)
+					model.DistributeNodes(slices.Collect(maps.Keys(ec.selectedNodes)), evt.Modifiers.Contain(key.ModShift))
+					ec.lazyUpdate = false
+				}
+			case "Z":
+				var changed bool
+				if evt.Modifiers.Contain(key.ModShift) {
+					changed = ec.history.Redo(m)
+				} else {
+					changed = ec.history.Undo(m)
+				}
+				if changed {
+					ec.lazyUpdate = false
+				}
 			}
 		}
 	}
 }
 
+// AlignSelection aligns the selected nodes, recording the change for undo
+func AlignSelection(m *model.Model, ec *EditContext, a model.Alignment) {
+	if len(ec.selectedNodes) < 2 {
+		return
+	}
+
+	ec.history.Record(m)
+	model.AlignNodes(slices.Collect(maps.Keys(ec.selectedNodes)), a)
+	ec.lazyUpdate = false
+}
+
 func LeftClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext) {
 	// Register for pan events on the entire window
 	event.Op(ops, leftClickTag)
@@ -194,9 +245,17 @@ func LeftClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 				if evt.Buttons != pointer.ButtonPrimary {
 					continue
 				}
+				// the toolbar handles its own clicks
+				if evt.Position.Round().In(ec.toolbarRect) {
+					ec.toolbarPressed = true
+					continue
+				}
 
 				// check if clicking a node
 				for _, n := range m.Nodes {
+					if !n.Visible {
+						continue
+					}
 
 					rect := utils.MakeRect(
 						n.Pos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
@@ -213,7 +272,13 @@ func LeftClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 							ec.draggedNode = n
 						}
 					case model.INTERCEPT:
-						//todo: handle intercept
+						if utils.WithinTriangle(evt.Position.Round(), rect) {
+							ec.draggedNode = n
+						}
+					case model.COMPOSITE:
+						if utils.WithinHexagon(evt.Position.Round(), rect) {
+							ec.draggedNode = n
+						}
 					}
 				}
 
@@ -222,6 +287,9 @@ func LeftClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 					if ec.draggedConnection != nil {
 						break
 					}
+					if !m.ShowsEstimate(c) {
+						continue
+					}
 
 					labRect := utils.MakeRect(
 						c.EstPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
@@ -233,26 +301,92 @@ func LeftClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 					}
 				}
 
+				// check if clicking the block of defined parameters
+				if ec.draggedNode == nil && ec.draggedConnection == nil && m.ShowsEffects() {
+					effects := m.EffectsRect()
+					rect := image.Rectangle{
+						Min: effects[0].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize).ToImagePnt(),
+						Max: effects[1].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize).ToImagePnt(),
+					}
+					ec.draggedEffects = evt.Position.Round().In(rect)
+				}
+
+				// check if clicking the box of fit indices
+				if ec.draggedNode == nil && ec.draggedConnection == nil && !ec.draggedEffects && m.ShowsFit() {
+					fit := m.FitRect()
+					rect := image.Rectangle{
+						Min: fit[0].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize).ToImagePnt(),
+						Max: fit[1].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize).ToImagePnt(),
+					}
+					ec.draggedFit = evt.Position.Round().In(rect)
+				}
+
+				shift := evt.Modifiers.Contain(key.ModShift)
+
+				// shift-clicking a selected node removes it from the selection and does nothing else
+				if n := ec.draggedNode; n != nil && shift && ec.selectedNodes[n] {
+					delete(ec.selectedNodes, n)
+					ec.draggedNode = nil
+					continue
+				}
+
 				if n := ec.draggedNode; n != nil { // if clicking a node ...
+					switch {
+					case shift:
+						ec.selectedNodes[n] = true
+					case !ec.selectedNodes[n]:
+						clear(ec.selectedNodes)
+						ec.selectedNodes[n] = true
+					}
 					ec.dragOffset = utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(n.Pos)
 				} else if c := ec.draggedConnection; c != nil {
 					ec.dragOffset = utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(c.EstPos)
+				} else if ec.draggedEffects {
+					ec.dragOffset = utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(m.EffectsPos)
+				} else if ec.draggedFit {
+					ec.dragOffset = utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(m.FitPos)
+				} else if shift { // if shift-clicking anywhere else, start a rubber-band selection
+					ec.selecting = true
+					ec.selectStart = utils.ToGlobalPosF32(evt.Position)
+					ec.selectEnd = ec.selectStart
+					ec.selectBase = maps.Clone(ec.selectedNodes)
 				} else { // if not clicking a node, then setup pan
+					clear(ec.selectedNodes)
 					ec.panClickPos = utils.ToLocalPos(evt.Position)
 					ec.panOffset = ec.viewportCenter
 				}
 
 			case pointer.Drag:
 				// Only respond to left mouse button
-				if evt.Buttons != pointer.ButtonPrimary {
+				if evt.Buttons != pointer.ButtonPrimary || ec.toolbarPressed {
 					continue
 				}
 
 				ec.lazyUpdate = false
+				// record the state before the first movement so a click without dragging adds no history
+				if (ec.draggedNode != nil || ec.draggedConnection != nil || ec.draggedEffects || ec.draggedFit) && !ec.dragRecorded {
+					ec.history.Record(m)
+					ec.dragRecorded = true
+				}
+
 				if n := ec.draggedNode; n != nil { // if dragging a node...
 					newPos := utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(ec.dragOffset)
-					n.Pos = utils.SnapToGrid(newPos, ec.snapGridSize)
 
+					// move the whole selection by the snapped movement of the dragged node
+					delta := utils.SnapToGrid(newPos, ec.snapGridSize).Sub(n.Pos)
+					for s := range ec.selectedNodes {
+						s.Pos = s.Pos.Add(delta)
+					}
+
+				} else if ec.draggedEffects {
+					newPos := utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(ec.dragOffset)
+					m.EffectsPos = utils.SnapToGrid(newPos, ec.snapGridSize)
+				} else if ec.draggedFit {
+					newPos := utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(ec.dragOffset)
+					m.FitPos = utils.SnapToGrid(newPos, ec.snapGridSize)
+				} else if ec.selecting {
+					ec.selectEnd = utils.ToGlobalPosF32(evt.Position)
+					SelectWithinRect(m, ec)
 				} else if c := ec.draggedConnection; c != nil {
 					newCursorPos := utils.ToLocalPos(evt.Position).Div(ec.scaleFactor).Sub(ec.dragOffset)
 					switch {
@@ -271,8 +405,13 @@ func LeftClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 					pointer.CursorGrab.Add(ops)
 				}
 			case pointer.Release:
+				ec.toolbarPressed = false
 				ec.draggedNode = nil
 				ec.draggedConnection = nil
+				ec.draggedEffects = false
+				ec.draggedFit = false
+				ec.dragRecorded = false
+				ec.selecting = false
 				ec.lazyUpdate = true
 				pointer.CursorDefault.Add(ops)
 			default:
@@ -309,6 +448,7 @@ func RightClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext
 					if WithinConnection(evt.Position.Round(), c, ec, tolerance, samples) {
 						if ec.editingSelection != c {
 							ec.editingSelection = c
+							ec.history.Record(m)
 							c.Curvature *= -1 // change curvature on right click
 						} else {
 							ec.editingSelection = nil
@@ -339,6 +479,22 @@ func RightClick(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext
 								ec.editingSelection = nil
 							}
 						}
+					case model.INTERCEPT:
+						if utils.WithinTriangle(evt.Position.Round(), rect) {
+							if ec.editingSelection != n {
+								ec.editingSelection = n
+							} else {
+								ec.editingSelection = nil
+							}
+						}
+					case model.COMPOSITE:
+						if utils.WithinHexagon(evt.Position.Round(), rect) {
+							if ec.editingSelection != n {
+								ec.editingSelection = n
+							} else {
+								ec.editingSelection = nil
+							}
+						}
 					}
 				}
 
@@ -393,9 +549,31 @@ func InitEditContext() *EditContext {
 	ec := new(EditContext)
 	ec.scaleFactor = 1.0
 	ec.snapGridSize = 20.0
+	ec.selectedNodes = make(map[*model.Node]bool)
 	return ec
 }
 
+// SelectWithinRect selects every visible node overlapping the rubber-band, in addition to the selection it started with
+func SelectWithinRect(m *model.Model, ec *EditContext) {
+	band := image.Rectangle{Min: ec.selectStart.ToImagePnt(), Max: ec.selectEnd.ToImagePnt()}.Canon()
+
+	clear(ec.selectedNodes)
+	maps.Copy(ec.selectedNodes, ec.selectBase)
+	for _, n := range m.Nodes {
+		if !n.Visible {
+			continue
+		}
+
+		rect := utils.MakeRect(
+			n.Pos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			n.Dim.ToGlobal(ec.scaleFactor),
+		)
+		if rect.Overlaps(band) {
+			ec.selectedNodes[n] = true
+		}
+	}
+}
+
 func WithinConnection(pos image.Point, c *model.Connection, ec *EditContext, tolerance float32, samples int) bool {
 	posA := c.OriginPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize)
 	posB := c.DestinationPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize)
@@ -416,6 +594,20 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 			continue
 		}
 
+		// highlight selected nodes with a margin around their bounds
+		if ec.selectedNodes[n] {
+			margin := utils.LocalDim{W: 12, H: 12}
+			utils.DrawSelectionRect(
+				ops,
+				utils.MakeRect(
+					n.Pos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+					n.Dim.Add(margin).ToGlobal(ec.scaleFactor),
+				),
+				selectionCol,
+				1.5,
+			)
+		}
+
 		switch n.Class {
 		case model.OBSERVED:
 			utils.DrawRect(
@@ -433,9 +625,30 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 				n.Col,
 				n.Thickness*ec.scaleFactor,
 			)
+		case model.INTERCEPT:
+			utils.DrawTriangle(
+				ops,
+				n.Pos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+				n.Dim.ToGlobal(ec.scaleFactor),
+				n.Col,
+				n.Thickness*ec.scaleFactor,
+			)
+		case model.COMPOSITE:
+			utils.DrawHexagon(
+				ops,
+				n.Pos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+				n.Dim.ToGlobal(ec.scaleFactor),
+				n.Col,
+				n.Thickness*ec.scaleFactor,
+			)
 		}
 
 		textOffset := utils.LocalDim{W: n.Dim.W/2.0 - n.Padding, H: m.Font.Size / (1.5 / m.PxPerDp)} // I think 1.5 is a magic number
+		if n.Class == model.INTERCEPT {
+			// center the text on the centroid of the triangle rather than its bounding box
+			textOffset.H -= n.Dim.H / 6
+		}
+		textOffset.H -= m.NodeTextShift(n)
 		utils.DrawText(
 			ops,
 			gtx,
@@ -445,6 +658,10 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 			unit.Sp(m.Font.Size),
 			ec.scaleFactor,
 		)
+
+		if m.ShowsThresholds(n) {
+			DrawThresholds(ops, gtx, m, n, ec)
+		}
 	}
 
 	for _, c := range m.Connections {
@@ -458,7 +675,7 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 				ops,
 				c.OriginPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
 				c.DestinationPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
-				c.Col,
+				m.ConnectionColor(c),
 				c.Thickness*ec.scaleFactor,
 				ec.windowSize,
 			)
@@ -467,7 +684,7 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 				ops,
 				c.OriginPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
 				c.DestinationPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
-				c.Col,
+				m.ConnectionColor(c),
 				c.Thickness*ec.scaleFactor,
 				c.Curvature,
 				ec.windowSize,
@@ -479,7 +696,7 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 				c.DestinationPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
 				c.RefPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
 				model.VarianceRadius*ec.scaleFactor,
-				c.Col,
+				m.ConnectionColor(c),
 				c.Thickness*ec.scaleFactor,
 				ec.windowSize,
 			)
@@ -487,23 +704,157 @@ func DrawModel(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext)
 	}
 	// draw estimate labels after ALL of the connections to ensure proper layering
 	for _, c := range m.Connections {
-		if !c.UserDefined && !m.ViewGenerated {
+		if !m.ShowsEstimate(c) {
 			continue
 		}
 
-		if m.CoeffDisplay != utils.NONE {
-			utils.DrawEstimate(
+		utils.DrawEstimate(
+			ops,
+			gtx,
+			c.EstPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			m.Font.Face,
+			m.Font.Size,
+			ec.scaleFactor,
+			c.EstPadding,
+			c.EstText,
+			c.EstDim,
+			c.EstWidth,
+		)
+	}
+
+	// the variance explained is drawn after the connections so that no arrow crosses the badges
+	for _, n := range m.Nodes {
+		if m.ShowsR2(n) {
+			DrawR2(ops, gtx, m, n, ec)
+		}
+	}
+
+	if m.ShowsEffects() {
+		DrawEffects(ops, gtx, m, ec)
+	}
+
+	if m.ShowsFit() {
+		DrawFit(ops, gtx, m, ec)
+	}
+
+	if ec.selecting {
+		band := image.Rectangle{Min: ec.selectStart.ToImagePnt(), Max: ec.selectEnd.ToImagePnt()}
+		utils.DrawSelectionRect(ops, band, selectionCol, 1)
+	}
+
+	// show which group's estimates are displayed
+	if m.IsMultiGroup() {
+		utils.DrawText(ops, gtx, utils.GlobalPos{X: 10, Y: 10}, m.GroupTitle(m.ActiveGroup), m.Font.Face, unit.Sp(m.Font.Size), 1)
+	}
+}
+
+// DrawThresholds draws the thresholds of an ordinal node below it, as tick marks or as a list
+func DrawThresholds(ops *op.Ops, gtx layout.Context, m *model.Model, n *model.Node, ec *EditContext) {
+	switch m.ThresholdDisplay {
+	case model.THRESHOLDS_TICKS:
+		for _, l := range model.ThresholdTicks(n) {
+			utils.DrawLine(
 				ops,
-				gtx,
-				c.EstPos.ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
-				m.Font.Face,
-				m.Font.Size,
-				ec.scaleFactor,
-				c.EstPadding,
-				c.EstText,
-				c.EstDim,
-				c.EstWidth,
+				l[0].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+				l[1].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+				color.NRGBA{A: 255},
+				model.ThresholdThickness*ec.scaleFactor,
 			)
 		}
+	case model.THRESHOLDS_LIST:
+		txt, center := m.ThresholdLabel(n)
+		size := m.ThresholdFontSize()
+		textOffset := utils.LocalDim{W: utils.GetTextWidth(txt, m.Font.Face, size, gtx) / 2, H: size / (1.5 / m.PxPerDp)}
+		utils.DrawText(
+			ops,
+			gtx,
+			center.SubDim(textOffset).ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			txt,
+			m.Font.Face,
+			unit.Sp(size),
+			ec.scaleFactor,
+		)
+	}
+}
+
+// DrawR2 draws the variance explained of an endogenous node under its name or as a badge
+func DrawR2(ops *op.Ops, gtx layout.Context, m *model.Model, n *model.Node, ec *EditContext) {
+	if m.R2Display == model.R2_BADGE {
+		rect := m.R2BadgeRect(n)
+		dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+		utils.DrawRect(
+			ops,
+			rect[0].AddDim(dim.Div(2)).ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			dim.ToGlobal(ec.scaleFactor),
+			color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+			model.R2BadgeThickness*ec.scaleFactor,
+		)
+	}
+
+	txt, center := m.R2Label(n)
+	size := m.R2FontSize()
+	textOffset := utils.LocalDim{W: utils.GetTextWidth(txt, m.Font.Face, size, gtx) / 2, H: size / (1.5 / m.PxPerDp)}
+	utils.DrawText(
+		ops,
+		gtx,
+		center.SubDim(textOffset).ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+		txt,
+		m.Font.Face,
+		unit.Sp(size),
+		ec.scaleFactor,
+	)
+}
+
+// DrawEffects draws the block of defined parameters
+func DrawEffects(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext) {
+	for _, l := range m.EffectsRules() {
+		utils.DrawLine(
+			ops,
+			l[0].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			l[1].ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			color.NRGBA{A: 255},
+			model.EffectsRuleThickness*ec.scaleFactor,
+		)
+	}
+
+	size := m.EffectsFontSize()
+	for _, t := range m.EffectsTexts() {
+		textOffset := utils.LocalDim{H: size / (1.5 / m.PxPerDp)}
+		utils.DrawText(
+			ops,
+			gtx,
+			t.Pos.SubDim(textOffset).ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			t.Text,
+			m.Font.Face,
+			unit.Sp(size),
+			ec.scaleFactor,
+		)
+	}
+}
+
+// DrawFit draws the box of fit indices
+func DrawFit(ops *op.Ops, gtx layout.Context, m *model.Model, ec *EditContext) {
+	rect := m.FitRect()
+	dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+	utils.DrawRect(
+		ops,
+		rect[0].AddDim(dim.Div(2)).ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+		dim.ToGlobal(ec.scaleFactor),
+		color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		model.FitBorderThickness*ec.scaleFactor,
+	)
+
+	size := m.FitFontSize()
+	for _, t := range m.FitTexts() {
+		textOffset := utils.LocalDim{H: size / (1.5 / m.PxPerDp)}
+		utils.DrawText(
+			ops,
+			gtx,
+			t.Pos.SubDim(textOffset).ToGlobal(ec.scaleFactor, ec.viewportCenter, ec.windowSize),
+			t.Text,
+			m.Font.Face,
+			unit.Sp(size),
+			ec.scaleFactor,
+		)
 	}
 }
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"slices"
+)
+
+type Alignment int
+
+const (
+	ALIGN_LEFT Alignment = iota
+	ALIGN_RIGHT
+	ALIGN_TOP
+	ALIGN_BOTTOM
+	ALIGN_CENTER_X // centers share the same x coordinate (a column)
+	ALIGN_CENTER_Y // centers share the same y coordinate (a row)
+)
+
+// AlignNodes lines up the edges or centers of the nodes. Edges are aligned to the outermost node
+func AlignNodes(nodes []*Node, a Alignment) {
+	if len(nodes) < 2 {
+		return
+	}
+
+	switch a {
+	case ALIGN_LEFT:
+		left := nodes[0].Pos.X - nodes[0].Dim.W/2
+		for _, n := range nodes {
+			left = min(left, n.Pos.X-n.Dim.W/2)
+		}
+		for _, n := range nodes {
+			n.Pos.X = left + n.Dim.W/2
+		}
+	case ALIGN_RIGHT:
+		right := nodes[0].Pos.X + nodes[0].Dim.W/2
+		for _, n := range nodes {
+			right = max(right, n.Pos.X+n.Dim.W/2)
+		}
+		for _, n := range nodes {
+			n.Pos.X = right - n.Dim.W/2
+		}
+	case ALIGN_TOP:
+		top := nodes[0].Pos.Y - nodes[0].Dim.H/2
+		for _, n := range nodes {
+			top = min(top, n.Pos.Y-n.Dim.H/2)
+		}
+		for _, n := range nodes {
+			n.Pos.Y = top + n.Dim.H/2
+		}
+	case ALIGN_BOTTOM:
+		bottom := nodes[0].Pos.Y + nodes[0].Dim.H/2
+		for _, n := range nodes {
+			bottom = max(bottom, n.Pos.Y+n.Dim.H/2)
+		}
+		for _, n := range nodes {
+			n.Pos.Y = bottom - n.Dim.H/2
+		}
+	case ALIGN_CENTER_X:
+		var sum float32
+		for _, n := range nodes {
+			sum += n.Pos.X
+		}
+		for _, n := range nodes {
+			n.Pos.X = sum / float32(len(nodes))
+		}
+	case ALIGN_CENTER_Y:
+		var sum float32
+		for _, n := range nodes {
+			sum += n.Pos.Y
+		}
+		for _, n := range nodes {
+			n.Pos.Y = sum / float32(len(nodes))
+		}
+	}
+}
+
+// DistributeNodes spaces the nodes so that the gaps between neighbouring nodes are equal.
+// The outermost nodes stay in place.
+func DistributeNodes(nodes []*Node, vertical bool) {
+	if len(nodes) < 3 {
+		return
+	}
+
+	// pos and size along the axis of distribution
+	pos := func(n *Node) *float32 {
+		if vertical {
+			return &n.Pos.Y
+		}
+		return &n.Pos.X
+	}
+	size := func(n *Node) float32 {
+		if vertical {
+			return n.Dim.H
+		}
+		return n.Dim.W
+	}
+
+	sorted := slices.Clone(nodes)
+	slices.SortFunc(sorted, func(a, b *Node) int {
+		switch {
+		case *pos(a) < *pos(b):
+			return -1
+		case *pos(a) > *pos(b):
+			return 1
+		}
+		return 0
+	})
+
+	first := sorted[0]
+	last := sorted[len(sorted)-1]
+	span := (*pos(last) + size(last)/2) - (*pos(first) - size(first)/2)
+
+	var totalSize float32
+	for _, n := range sorted {
+		totalSize += size(n)
+	}
+	gap := (span - totalSize) / float32(len(sorted)-1)
+
+	edge := *pos(first) - size(first)/2
+	for _, n := range sorted {
+		*pos(n) = edge + size(n)/2
+		edge += size(n) + gap
+	}
+}
@@ -22,6 +22,32 @@ import (
 
 var VarianceRadius float32 = 20
 var targetPadding float32 = 10
+var interceptDim = utils.LocalDim{W: 40, H: 36}
+
+// NodeDim returns the size a node needs to fit its text. The text of a node that has not been drawn yet is measured
+// without being stored
+func (m *Model) NodeDim(n *Node, gtx layout.Context) utils.LocalDim {
+	textWidth := n.TextWidth
+	if textWidth == 0 {
+		textWidth = utils.GetTextWidth(n.Text, m.Font.Face, m.Font.Size, gtx)
+	}
+
+	// todo: decide whether to snap dimensions to grid as well as position
+	//adjWidth := utils.SnapValue(textWidth+targetPadding*2, ec.snapGridSize)
+	extraW, extraH := m.r2InsideDim(n, textWidth, gtx)
+	adjWidth := textWidth + extraW + targetPadding*2
+	switch n.Class {
+	case LATENT:
+		return utils.LocalDim{W: adjWidth + extraH, H: adjWidth + extraH}
+	case INTERCEPT:
+		return interceptDim
+	case COMPOSITE:
+		// widen the box so that the text fits between the slanted edges of the hexagon
+		return utils.LocalDim{W: adjWidth + 25, H: 50 + extraH}
+	default:
+		return utils.LocalDim{W: adjWidth, H: 50 + extraH}
+	}
+}
 
 func CalculateModel(m *Model, gtx layout.Context) {
 	// Reset all node connections every frame
@@ -35,18 +61,8 @@ func CalculateModel(m *Model, gtx layout.Context) {
 		if n.TextWidth == 0 {
 			n.TextWidth = utils.GetTextWidth(n.Text, m.Font.Face, m.Font.Size, gtx)
 		}
-		// todo: decide whether to snap dimensions to grid as well as position
-		//adjWidth := utils.SnapValue(textWidth+targetPadding*2, ec.snapGridSize)
-		adjWidth := n.TextWidth + targetPadding*2
-		n.Padding = (adjWidth - n.TextWidth) / 2.0
-		switch n.Class {
-		case OBSERVED:
-			n.Dim = utils.LocalDim{W: adjWidth, H: 50}
-		case LATENT:
-			n.Dim = utils.LocalDim{W: adjWidth, H: adjWidth}
-		case INTERCEPT:
-			//todo: handle intercepts
-		}
+		n.Dim = m.NodeDim(n, gtx)
+		n.Padding = (n.Dim.W - n.TextWidth) / 2.0
 	}
 
 	// Rule for connections:
@@ -172,19 +188,45 @@ func CalculateModel(m *Model, gtx layout.Context) {
 
 		// calculate estimate label width once and then store (the text width calculation is VERY expensive)
 		if c.EstWidth == 0 {
-			c.EstText, c.EstDim, c.EstWidth = utils.CalculateEstimate(m.Font.Face, m.Font.Size-2, m.CoeffDisplay, c.Est, c.PValue, c.CI, 2, c.EstPadding, gtx)
+			c.EstText = m.estimateText(c)
+			c.EstDim, c.EstWidth = utils.MeasureEstimate(c.EstText, m.Font.Face, m.Font.Size-2, c.EstPadding, gtx)
 		}
 
 		switch {
 		case c.Type != CIRCULAR:
+			if c.Origin.Class == INTERCEPT {
+				target := c.DestinationPos
+				if c.Destination.Class == LATENT {
+					target = c.Destination.Pos
+				}
+				angleFromIntercept := utils.GetAngleLoc(c.Origin.Pos, target)
+				vertices := utils.TriangleVertices(c.Origin.Pos, c.Origin.Dim)
+				c.OriginPos = utils.RayPolygonIntersection(c.Origin.Pos, angleFromIntercept, vertices[:])
+			}
+			if c.Origin.Class == COMPOSITE {
+				target := c.DestinationPos
+				if c.Destination.Class == LATENT || c.Destination.Class == COMPOSITE {
+					target = c.Destination.Pos
+				}
+				vertices := utils.HexagonVertices(c.Origin.Pos, c.Origin.Dim)
+				c.OriginPos = utils.RayPolygonIntersection(c.Origin.Pos, utils.GetAngleLoc(c.Origin.Pos, target), vertices[:])
+			}
 			if c.Origin.Class == LATENT {
-				angleFromLatent := utils.GetAngleLoc(c.Origin.Pos, c.DestinationPos)
+				target := c.DestinationPos
+				if c.Destination.Class == COMPOSITE {
+					target = c.Destination.Pos
+				}
+				angleFromLatent := utils.GetAngleLoc(c.Origin.Pos, target)
 				c.OriginPos = utils.MoveAlongAngleLoc(c.Origin.Pos, angleFromLatent, c.Origin.Dim.W/2.0)
 			}
 			if c.Destination.Class == LATENT {
 				angleToLatent := utils.GetAngleLoc(c.OriginPos, c.Destination.Pos)
 				c.DestinationPos = utils.MoveAlongAngleLoc(c.Destination.Pos, angleToLatent+math.Pi, c.Destination.Dim.W/2.0)
 			}
+			if c.Destination.Class == COMPOSITE {
+				vertices := utils.HexagonVertices(c.Destination.Pos, c.Destination.Dim)
+				c.DestinationPos = utils.RayPolygonIntersection(c.Destination.Pos, utils.GetAngleLoc(c.Destination.Pos, c.OriginPos), vertices[:])
+			}
 
 			// determine label position as distance along curve
 			switch c.Type {
@@ -210,21 +252,36 @@ func CalculateModel(m *Model, gtx layout.Context) {
 				c.OriginPos = utils.AngleRectIntersection(angleOrigin, c.Origin.Pos, c.Origin.Dim)
 				c.DestinationPos = utils.AngleRectIntersection(angleDestination, c.Origin.Pos, c.Origin.Dim)
 				c.RefPos = utils.AngleRectIntersection(c.VarianceAngle, c.Origin.Pos, c.Origin.Dim.Add(utils.LocalDim{5, 5}))
+			case COMPOSITE:
+				// the disturbance of a composite sits on its hexagon like a variance on a rectangle
+				vertices := utils.HexagonVertices(c.Origin.Pos, c.Origin.Dim)
+				refVertices := utils.HexagonVertices(c.Origin.Pos, c.Origin.Dim.Add(utils.LocalDim{W: 5, H: 5}))
+
+				c.OriginPos = utils.RayPolygonIntersection(c.Origin.Pos, utils.NormalizeAngle(c.VarianceAngle-math.Pi/8), vertices[:])
+				c.DestinationPos = utils.RayPolygonIntersection(c.Origin.Pos, utils.NormalizeAngle(c.VarianceAngle+math.Pi/8), vertices[:])
+				c.RefPos = utils.RayPolygonIntersection(c.Origin.Pos, c.VarianceAngle, refVertices[:])
 			}
 			circleCenter := utils.FindCircleCenter(c.OriginPos.ToF32(), c.DestinationPos.ToF32(), c.RefPos.ToF32(), VarianceRadius)
 			c.EstPos = utils.MoveAlongAngleLoc(utils.ToLocalPos(circleCenter), c.VarianceAngle, VarianceRadius)
 		}
 	}
+
+	if !m.effects.valid {
+		calculateEffects(m, gtx)
+	}
+	if !m.fit.valid {
+		calculateFit(m, gtx)
+	}
 }
 
 func AssignToEdges(c *Connection, nodes []*Node) {
 	switch {
-	case c.Origin.Class == OBSERVED && c.Destination.Class == LATENT:
+	case c.Origin.Class == OBSERVED && (c.Destination.Class == LATENT || c.Destination.Class == COMPOSITE):
 		candidateOriginEdges := GetCandidateDestEdges(InvertAngle(c))
 		edgeOrigin := GetBestEdge(candidateOriginEdges, c.Origin, c.Destination, c.Destination.Pos, nodes)
 		c.Origin.EdgeConnections[edgeOrigin] = append(c.Origin.EdgeConnections[edgeOrigin], c)
 
-	case c.Origin.Class == LATENT && c.Destination.Class == OBSERVED:
+	case (c.Origin.Class == LATENT || c.Origin.Class == INTERCEPT || c.Origin.Class == COMPOSITE) && c.Destination.Class == OBSERVED:
 		candidateOriginEdges := GetCandidateDestEdges(c.Angle)
 		edgeDest := GetBestEdge(candidateOriginEdges, c.Destination, c.Origin, c.Origin.Pos, nodes)
 		c.Destination.EdgeConnections[edgeDest] = append(c.Destination.EdgeConnections[edgeDest], c)
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"maps"
+	"slices"
+)
+
 func (m *Model) Clone() *Model {
 	if m == nil {
 		return nil
@@ -22,18 +27,45 @@ func (m *Model) Clone() *Model {
 		newConnections[i] = c.Clone(nodeMap)
 	}
 
+	newParams := make([]*DefinedParameter, len(m.DefinedParams))
+	for i, p := range m.DefinedParams {
+		newParams[i] = p.Clone()
+	}
+
 	return &Model{
-		Nodes:         newNodes,
-		Connections:   newConnections,
-		Font:          m.Font,
-		CoeffDisplay:  m.CoeffDisplay,
-		ViewGenerated: m.ViewGenerated,
-		PxPerDp:       m.PxPerDp,
+		Nodes:                newNodes,
+		Connections:          newConnections,
+		Font:                 m.Font,
+		CoeffDisplay:         m.CoeffDisplay,
+		Bayesian:             m.Bayesian,
+		ViewGenerated:        m.ViewGenerated,
+		PxPerDp:              m.PxPerDp,
+		Groups:               slices.Clone(m.Groups),
+		ActiveGroup:          m.ActiveGroup,
+		GroupPanels:          m.GroupPanels,
+		ThresholdDisplay:     m.ThresholdDisplay,
+		R2Display:            m.R2Display,
+		Solution:             m.Solution,
+		LabelTemplate:        m.LabelTemplate,
+		Precision:            m.Precision,
+		LabelDisplay:         m.LabelDisplay,
+		HighlightConstraints: m.HighlightConstraints,
+		DefinedParams:        newParams,
+		EffectsDisplay:       m.EffectsDisplay,
+		EffectsPos:           m.EffectsPos,
+		Fit:                  maps.Clone(m.Fit),
+		ShowFit:              m.ShowFit,
+		FitPos:               m.FitPos,
 	}
 }
 
 // deepCopy creates a deep copy of a Node
 func (n *Node) Clone() *Node {
+	thresholds := make([]*Threshold, len(n.Thresholds))
+	for i, t := range n.Thresholds {
+		thresholds[i] = t.Clone()
+	}
+
 	return &Node{
 		Class:       n.Class,
 		Pos:         n.Pos,
@@ -47,6 +79,9 @@ func (n *Node) Clone() *Node {
 		UserDefined: n.UserDefined,
 		Visible:     n.Visible,
 		Padding:     n.Padding,
+		Thresholds:  thresholds,
+		R2:          n.R2,
+		GroupR2:     maps.Clone(n.GroupR2),
 	}
 }
 
@@ -69,10 +104,17 @@ func (c *Connection) Clone(nodeMap map[string]*Node) *Connection {
 		Est:            c.Est,
 		PValue:         c.PValue,
 		CI:             c.CI,
+		Median:         c.Median,
+		Rhat:           c.Rhat,
+		SE:             c.SE,
+		Fixed:          c.Fixed,
+		Std:            c.Std,
 		EstText:        c.EstText,
+		Label:          c.Label,
 		Bold:           c.Bold,
 		Curvature:      c.Curvature,
 		UserDefined:    c.UserDefined,
+		GroupEstimates: maps.Clone(c.GroupEstimates),
 	}
 
 	// Remap node pointers to the new copies
@@ -12,7 +12,8 @@ type ParamType int
 const (
 	OBSERVED ParamType = iota
 	LATENT
-	INTERCEPT //TODO: add support for intercepts (triangle nodes)
+	INTERCEPT
+	COMPOSITE // formed from its indicators with "<~"
 )
 
 type ConnectionType int
@@ -37,31 +38,41 @@ type Node struct {
 	Visible         bool             `json:"visible,omitempty"`
 	EdgeConnections [4][]*Connection `json:"-"` // only applicable for rectangular nodes
 	Padding         float32          `json:"padding,omitempty"`
+	Thresholds      []*Threshold     `json:"thresholds,omitempty"` // only applicable for ordinal observed nodes
+	R2              float64          `json:"r2,omitempty"`
+	GroupR2         map[int]float64  `json:"group_r2,omitempty"` // only applicable for endogenous nodes
 }
 
 type Connection struct {
-	Origin         *Node          `json:"origin,omitempty"`
-	Destination    *Node          `json:"destination,omitempty"`
-	OriginPos      utils.LocalPos `json:"origin_pos"`
-	DestinationPos utils.LocalPos `json:"destination_pos"`
-	RefPos         utils.LocalPos `json:"ref_pos"`        // only applicable for circular connections
-	VarianceAngle  float64        `json:"variance_angle"` // only applicable for circular connections
-	Angle          float64        `json:"angle,omitempty"`
-	Col            color.NRGBA    `json:"col"`
-	Thickness      float32        `json:"thickness,omitempty"`
-	Type           ConnectionType `json:"type,omitempty"`
-	EstPos         utils.LocalPos `json:"est_pos"`
-	EstDim         utils.LocalDim `json:"est_dim"`
-	EstPadding     float32        `json:"est_padding,omitempty"`
-	EstWidth       float32        `json:"est_width,omitempty"`
-	AlongLineProp  float32        `json:"along_line_prop,omitempty"`
-	Est            float64        `json:"est,omitempty"`
-	PValue         float64        `json:"p_value,omitempty"`
-	CI             [2]float64     `json:"ci,omitempty"`
-	EstText        string         `json:"est_text,omitempty"`
-	Bold           bool           `json:"bold,omitempty"`
-	Curvature      float32        `json:"curvature,omitempty"`
-	UserDefined    bool           `json:"user_defined,omitempty"`
+	Origin         *Node            `json:"origin,omitempty"`
+	Destination    *Node            `json:"destination,omitempty"`
+	OriginPos      utils.LocalPos   `json:"origin_pos"`
+	DestinationPos utils.LocalPos   `json:"destination_pos"`
+	RefPos         utils.LocalPos   `json:"ref_pos"`        // only applicable for circular connections
+	VarianceAngle  float64          `json:"variance_angle"` // only applicable for circular connections
+	Angle          float64          `json:"angle,omitempty"`
+	Col            color.NRGBA      `json:"col"`
+	Thickness      float32          `json:"thickness,omitempty"`
+	Type           ConnectionType   `json:"type,omitempty"`
+	EstPos         utils.LocalPos   `json:"est_pos"`
+	EstDim         utils.LocalDim   `json:"est_dim"`
+	EstPadding     float32          `json:"est_padding,omitempty"`
+	EstWidth       float32          `json:"est_width,omitempty"`
+	AlongLineProp  float32          `json:"along_line_prop,omitempty"`
+	Est            float64          `json:"est,omitempty"`
+	PValue         float64          `json:"p_value,omitempty"`
+	CI             [2]float64       `json:"ci,omitempty"` // the credible interval of Bayesian models
+	Median         float64          `json:"median,omitempty"`
+	Rhat           float64          `json:"rhat,omitempty"`
+	SE             float64          `json:"se,omitempty"`
+	Fixed          bool             `json:"fixed,omitempty"`
+	Std            *Estimate        `json:"std,omitempty"` // the standardized solution, if the input has one
+	EstText        string           `json:"est_text,omitempty"`
+	Label          string           `json:"label,omitempty"` // parameters sharing a label are constrained to be equal
+	Bold           bool             `json:"bold,omitempty"`
+	Curvature      float32          `json:"curvature,omitempty"`
+	UserDefined    bool             `json:"user_defined,omitempty"`
+	GroupEstimates map[int]Estimate `json:"group_estimates,omitempty"`
 }
 
 type FontSettings struct {
@@ -71,11 +82,30 @@ type FontSettings struct {
 }
 
 type Model struct {
-	Nodes         []*Node                  `json:"nodes,omitempty"`
-	Connections   []*Connection            `json:"connections,omitempty"`
-	Network       map[*Node][]*Node        `json:"-"`
-	Font          FontSettings             `json:"font"`
-	CoeffDisplay  utils.CoefficientDisplay `json:"coeff_display,omitempty"`
-	ViewGenerated bool                     `json:"view_generated,omitempty"`
-	PxPerDp       float32                  `json:"px_per_dp,omitempty"`
+	Nodes                []*Node                  `json:"nodes,omitempty"`
+	Connections          []*Connection            `json:"connections,omitempty"`
+	Network              map[*Node][]*Node        `json:"-"`
+	Font                 FontSettings             `json:"font"`
+	CoeffDisplay         utils.CoefficientDisplay `json:"coeff_display,omitempty"`
+	Bayesian             bool                     `json:"bayesian,omitempty"` // estimates summarise posterior distributions
+	ViewGenerated        bool                     `json:"view_generated,omitempty"`
+	PxPerDp              float32                  `json:"px_per_dp,omitempty"`
+	Groups               []int                    `json:"groups,omitempty"`
+	ActiveGroup          int                      `json:"active_group,omitempty"`
+	GroupPanels          bool                     `json:"group_panels,omitempty"` // export groups side by side instead of separately
+	ThresholdDisplay     ThresholdDisplay         `json:"threshold_display,omitempty"`
+	R2Display            R2Display                `json:"r2_display,omitempty"`
+	Solution             Solution                 `json:"solution,omitempty"`
+	LabelTemplate        string                   `json:"label_template,omitempty"` // e.g. "{est}{stars} [{lo}, {hi}]", see ParseLabelTemplate
+	Precision            *int                     `json:"precision,omitempty"`      // decimal places of the estimates, see EstimatePrecision
+	LabelDisplay         LabelDisplay             `json:"label_display,omitempty"`
+	HighlightConstraints bool                     `json:"highlight_constraints,omitempty"`
+	DefinedParams        []*DefinedParameter      `json:"defined_params,omitempty"`
+	EffectsDisplay       EffectsDisplay           `json:"effects_display,omitempty"`
+	EffectsPos           utils.LocalPos           `json:"effects_pos"` // top-left corner of the block of defined parameters
+	effects              effectsLayout
+	Fit                  FitMeasures    `json:"fit,omitempty"`
+	ShowFit              bool           `json:"show_fit,omitempty"`
+	FitPos               utils.LocalPos `json:"fit_pos"` // top-left corner of the box of fit indices
+	fit                  fitLayout
 }
@@ -0,0 +1,298 @@
+package model
+
+import (
+	"fmt"
+	"main/utils"
+	"maps"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+)
+
+type EffectsDisplay int
+
+const (
+	EFFECTS_HIDDEN  EffectsDisplay = iota
+	EFFECTS_TABLE                  // one row per defined parameter with its estimate, interval and p-value
+	EFFECTS_CAPTION                // the defined parameters written out as a note
+)
+
+func (d EffectsDisplay) String() string {
+	switch d {
+	case EFFECTS_TABLE:
+		return "table"
+	case EFFECTS_CAPTION:
+		return "caption"
+	default:
+		return "hidden"
+	}
+}
+
+const (
+	effectsGap           float32 = 30  // space between the diagram and the block when it is first shown
+	effectsColumnGap     float32 = 16  // space between the columns of the table
+	effectsRulePadding   float32 = 4   // space between a rule of the table and the text
+	effectsCaptionWidth  float32 = 480 // lines of the caption are wrapped at this width
+	EffectsRuleThickness float32 = 1
+)
+
+// DefinedParameter is a parameter defined with ":=", e.g. the indirect effect "ab := a*b" of a mediation model
+type DefinedParameter struct {
+	Name           string           `json:"name"`
+	Expr           string           `json:"expr,omitempty"`
+	Est            float64          `json:"est,omitempty"`
+	PValue         float64          `json:"p_value,omitempty"`
+	CI             [2]float64       `json:"ci,omitempty"`
+	Std            *Estimate        `json:"std,omitempty"` // the standardized solution, if the input has one
+	GroupEstimates map[int]Estimate `json:"group_estimates,omitempty"`
+}
+
+func (p *DefinedParameter) Clone() *DefinedParameter {
+	res := *p
+	res.GroupEstimates = maps.Clone(p.GroupEstimates)
+	return &res
+}
+
+// TextLine is a piece of text of a block drawn on the canvas, such as the defined parameters. Pos is the left end of
+// the line through its vertical center, since the width of the text differs between the editor and the export formats
+type TextLine struct {
+	Text string
+	Pos  utils.LocalPos
+}
+
+// effectsLayout holds the text and rules of the effects block relative to its top-left corner
+type effectsLayout struct {
+	valid bool
+	texts []TextLine
+	rules [][2]utils.LocalPos
+	dim   utils.LocalDim
+}
+
+// NextEffectsDisplay switches between hiding the defined parameters, a table and a caption. The block is placed below
+// the diagram the first time it is shown
+func (m *Model) NextEffectsDisplay() {
+	if m.EffectsDisplay == EFFECTS_HIDDEN && m.EffectsPos == (utils.LocalPos{}) && len(m.Nodes) > 0 {
+		rect, _ := GetModelSize(m)
+		m.EffectsPos = utils.SnapToGrid(utils.LocalPos{X: rect[0].X, Y: rect[1].Y + effectsGap}, 20)
+	}
+	m.EffectsDisplay = (m.EffectsDisplay + 1) % (EFFECTS_CAPTION + 1)
+	m.effects.valid = false
+}
+
+// ShowsEffects reports whether the block of defined parameters is drawn
+func (m *Model) ShowsEffects() bool {
+	return m.EffectsDisplay != EFFECTS_HIDDEN && len(m.DefinedParams) > 0
+}
+
+// EffectsRect returns the NW and SE corners of the block of defined parameters
+func (m *Model) EffectsRect() [2]utils.LocalPos {
+	l := m.effectsLayout()
+	return [2]utils.LocalPos{m.EffectsPos, m.EffectsPos.Add(utils.LocalPos{X: l.dim.W, Y: l.dim.H})}
+}
+
+// EffectsTexts returns the text of the block of defined parameters
+func (m *Model) EffectsTexts() []TextLine {
+	l := m.effectsLayout()
+	res := make([]TextLine, len(l.texts))
+	for i, t := range l.texts {
+		t.Pos = t.Pos.Add(m.EffectsPos)
+		res[i] = t
+	}
+	return res
+}
+
+// EffectsRules returns the horizontal rules of the table
+func (m *Model) EffectsRules() [][2]utils.LocalPos {
+	l := m.effectsLayout()
+	res := make([][2]utils.LocalPos, len(l.rules))
+	for i, r := range l.rules {
+		res[i] = [2]utils.LocalPos{r[0].Add(m.EffectsPos), r[1].Add(m.EffectsPos)}
+	}
+	return res
+}
+
+// EffectsFontSize is the size of the block, matching the estimate labels
+func (m *Model) EffectsFontSize() float32 {
+	return m.Font.Size - 2
+}
+
+// effectsLayout returns the layout calculated with the model, or calculates it as on a screen with one pixel per dp
+func (m *Model) effectsLayout() effectsLayout {
+	if !m.effects.valid {
+		calculateEffects(m, layout.Context{})
+	}
+	return m.effects
+}
+
+func calculateEffects(m *Model, gtx layout.Context) {
+	m.effects = effectsLayout{valid: true}
+	if !m.ShowsEffects() {
+		return
+	}
+
+	size := m.EffectsFontSize()
+	// text widths are measured in pixels, so the line height is scaled to match
+	pxPerDp := float32(1)
+	if gtx.Metric.PxPerDp != 0 {
+		pxPerDp = gtx.Metric.PxPerDp
+	}
+	lineHeight := size * 1.5 * pxPerDp
+	width := func(txt string) float32 {
+		return utils.GetTextWidth(txt, m.Font.Face, size, gtx)
+	}
+
+	switch m.EffectsDisplay {
+	case EFFECTS_TABLE:
+		m.effects.texts, m.effects.rules, m.effects.dim = effectsTable(m, lineHeight, pxPerDp, width)
+	case EFFECTS_CAPTION:
+		m.effects.texts, m.effects.dim = effectsCaption(m, lineHeight, pxPerDp, width)
+	}
+}
+
+// effectsTable lays out a table with a rule above and below the header and below the last row
+func effectsTable(m *Model, lineHeight, pxPerDp float32, width func(string) float32) ([]TextLine, [][2]utils.LocalPos, utils.LocalDim) {
+	header := []string{"Parameter"}
+	hasExpr := false
+	for _, p := range m.DefinedParams {
+		hasExpr = hasExpr || p.Expr != ""
+	}
+	if hasExpr {
+		header = append(header, "Definition")
+	}
+	hasEstimates := m.CoeffDisplay != utils.NONE
+	switch {
+	case hasEstimates && m.Bayesian:
+		// posterior summaries have no p-values
+		header = append(header, "Estimate", "95% CrI")
+	case hasEstimates:
+		header = append(header, "Estimate", "95% CI", "p")
+	}
+
+	cells := [][]string{header}
+	for _, p := range m.DefinedParams {
+		row := []string{p.Name}
+		if hasExpr {
+			row = append(row, p.Expr)
+		}
+		e := m.definedEstimate(p)
+		switch {
+		case hasEstimates && m.Bayesian:
+			row = append(row, m.formatEffect(e.Est), m.formatInterval(e.CI))
+		case hasEstimates:
+			row = append(row, m.formatEffect(e.Est), m.formatInterval(e.CI), formatPValue(e.PValue))
+		}
+		cells = append(cells, row)
+	}
+
+	colWidths := make([]float32, len(header))
+	for _, row := range cells {
+		for j, txt := range row {
+			colWidths[j] = max(colWidths[j], width(txt))
+		}
+	}
+
+	gap := effectsColumnGap * pxPerDp
+	rulePadding := effectsRulePadding * pxPerDp
+	var texts []TextLine
+	y := rulePadding
+	for i, row := range cells {
+		if i == 1 {
+			// leave room for the rule below the header
+			y += 2 * rulePadding
+		}
+		x := float32(0)
+		for j, txt := range row {
+			texts = append(texts, TextLine{Text: txt, Pos: utils.LocalPos{X: x, Y: y + lineHeight/2}})
+			x += colWidths[j] + gap
+		}
+		y += lineHeight
+	}
+
+	w := -gap
+	for _, cw := range colWidths {
+		w += cw + gap
+	}
+	h := y + rulePadding
+	headerBottom := rulePadding + lineHeight + rulePadding
+	rules := [][2]utils.LocalPos{
+		{{X: 0, Y: 0}, {X: w, Y: 0}},
+		{{X: 0, Y: headerBottom}, {X: w, Y: headerBottom}},
+		{{X: 0, Y: h}, {X: w, Y: h}},
+	}
+
+	return texts, rules, utils.LocalDim{W: w, H: h}
+}
+
+// effectsCaption writes the defined parameters out as a note, e.g. "ab = 0.25, 95% CI [0.10, 0.40], p = .003",
+// wrapping the lines at effectsCaptionWidth
+func effectsCaption(m *Model, lineHeight, pxPerDp float32, width func(string) float32) ([]TextLine, utils.LocalDim) {
+	parts := make([]string, len(m.DefinedParams))
+	for i, p := range m.DefinedParams {
+		e := m.definedEstimate(p)
+		switch {
+		case m.CoeffDisplay == utils.NONE && p.Expr == "":
+			parts[i] = p.Name
+		case m.CoeffDisplay == utils.NONE:
+			parts[i] = p.Name + " := " + p.Expr
+		case m.Bayesian:
+			parts[i] = fmt.Sprintf("%s = %s, 95%% CrI %s", p.Name, m.formatEffect(e.Est), m.formatInterval(e.CI))
+		case e.CI == [2]float64{}:
+			parts[i] = fmt.Sprintf("%s = %s, p %s", p.Name, m.formatEffect(e.Est), formatPComparison(e.PValue))
+		default:
+			parts[i] = fmt.Sprintf("%s = %s, 95%% CI %s, p %s", p.Name, m.formatEffect(e.Est), m.formatInterval(e.CI), formatPComparison(e.PValue))
+		}
+	}
+	words := strings.Fields("Note. " + strings.Join(parts, "; ") + ".")
+
+	maxWidth := effectsCaptionWidth * pxPerDp
+	var lines []string
+	line := words[0]
+	for _, w := range words[1:] {
+		if width(line+" "+w) > maxWidth {
+			lines = append(lines, line)
+			line = w
+			continue
+		}
+		line += " " + w
+	}
+	lines = append(lines, line)
+
+	texts := make([]TextLine, len(lines))
+	var dim utils.LocalDim
+	for i, l := range lines {
+		texts[i] = TextLine{Text: l, Pos: utils.LocalPos{Y: (float32(i) + .5) * lineHeight}}
+		dim.W = max(dim.W, width(l))
+	}
+	dim.H = float32(len(lines)) * lineHeight
+
+	return texts, dim
+}
+
+func (m *Model) formatEffect(v float64) string {
+	return strconv.FormatFloat(v, 'f', m.EstimatePrecision(), 64)
+}
+
+func (m *Model) formatInterval(ci [2]float64) string {
+	return "[" + m.formatEffect(ci[0]) + ", " + m.formatEffect(ci[1]) + "]"
+}
+
+// definedEstimate returns the estimate of the defined parameter in the solution shown first
+func (m *Model) definedEstimate(p *DefinedParameter) Estimate {
+	return m.shownEstimate(Estimate{Est: p.Est, PValue: p.PValue, CI: p.CI, Std: p.Std})
+}
+
+// formatPValue drops the leading zero, since p-values cannot exceed one
+func formatPValue(p float64) string {
+	if p < .001 {
+		return "< .001"
+	}
+	return strings.TrimPrefix(strconv.FormatFloat(p, 'f', 3, 64), "0")
+}
+
+func formatPComparison(p float64) string {
+	if p < .001 {
+		return "< .001"
+	}
+	return "= " + formatPValue(p)
+}
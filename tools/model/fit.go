@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"main/utils"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+)
+
+// FitMeasures holds the fit indices of a model by their names in lavaan's fitMeasures, e.g. "cfi" or "rmsea.ci.lower"
+type FitMeasures map[string]float64
+
+const (
+	fitGap             float32 = 30 // space between the diagram and the box when it is first shown
+	fitPadding         float32 = 8  // space between the border of the box and the text
+	FitBorderThickness float32 = 2  // half of the outline lies outside of the box and is not drawn, as for nodes
+)
+
+// fitLayout holds the text of the fit box relative to its top-left corner
+type fitLayout struct {
+	valid bool
+	texts []TextLine
+	dim   utils.LocalDim
+}
+
+// ToggleFit shows or hides the box of fit indices. The box is placed to the right of the diagram the first time it is
+// shown
+func (m *Model) ToggleFit() {
+	if !m.ShowFit && m.FitPos == (utils.LocalPos{}) && len(m.Nodes) > 0 {
+		rect, _ := GetModelSize(m)
+		m.FitPos = utils.SnapToGrid(utils.LocalPos{X: rect[1].X + fitGap, Y: rect[0].Y}, 20)
+	}
+	m.ShowFit = !m.ShowFit
+	m.fit.valid = false
+}
+
+// ShowsFit reports whether the box of fit indices is drawn
+func (m *Model) ShowsFit() bool {
+	return m.ShowFit && len(m.FitLines()) > 0
+}
+
+// FitRect returns the NW and SE corners of the box of fit indices
+func (m *Model) FitRect() [2]utils.LocalPos {
+	l := m.fitLayout()
+	return [2]utils.LocalPos{m.FitPos, m.FitPos.Add(utils.LocalPos{X: l.dim.W, Y: l.dim.H})}
+}
+
+// FitTexts returns the lines of the box of fit indices
+func (m *Model) FitTexts() []TextLine {
+	l := m.fitLayout()
+	res := make([]TextLine, len(l.texts))
+	for i, t := range l.texts {
+		t.Pos = t.Pos.Add(m.FitPos)
+		res[i] = t
+	}
+	return res
+}
+
+// FitFontSize is the size of the box, matching the estimate labels
+func (m *Model) FitFontSize() float32 {
+	return m.Font.Size - 2
+}
+
+// FitLines formats the usual fit indices, one per line, e.g. "χ²(24) = 35.21, p = .066" and "RMSEA = .041 [.000, .070]".
+// Indices missing from the input are left out
+func (m *Model) FitLines() []string {
+	f := m.Fit
+	var lines []string
+	if chisq, ok := f["chisq"]; ok {
+		line := "χ²(" + strconv.FormatFloat(f["df"], 'f', -1, 64) + ") = " + strconv.FormatFloat(chisq, 'f', 2, 64)
+		if p, ok := f["pvalue"]; ok {
+			line += ", p " + formatPComparison(p)
+		}
+		lines = append(lines, line)
+	}
+	for _, name := range []string{"cfi", "tli"} {
+		if v, ok := f[name]; ok {
+			lines = append(lines, strings.ToUpper(name)+" = "+formatFitIndex(v))
+		}
+	}
+	if rmsea, ok := f["rmsea"]; ok {
+		line := "RMSEA = " + formatFitIndex(rmsea)
+		lower, hasLower := f["rmsea.ci.lower"]
+		upper, hasUpper := f["rmsea.ci.upper"]
+		if hasLower && hasUpper {
+			line += fmt.Sprintf(" [%s, %s]", formatFitIndex(lower), formatFitIndex(upper))
+		}
+		lines = append(lines, line)
+	}
+	if srmr, ok := f["srmr"]; ok {
+		lines = append(lines, "SRMR = "+formatFitIndex(srmr))
+	}
+	return lines
+}
+
+// fitLayout returns the layout calculated with the model, or calculates it as on a screen with one pixel per dp
+func (m *Model) fitLayout() fitLayout {
+	if !m.fit.valid {
+		calculateFit(m, layout.Context{})
+	}
+	return m.fit
+}
+
+func calculateFit(m *Model, gtx layout.Context) {
+	m.fit = fitLayout{valid: true}
+	if !m.ShowsFit() {
+		return
+	}
+
+	size := m.FitFontSize()
+	// text widths are measured in pixels, so the line height and padding are scaled to match
+	pxPerDp := float32(1)
+	if gtx.Metric.PxPerDp != 0 {
+		pxPerDp = gtx.Metric.PxPerDp
+	}
+	lineHeight := size * 1.5 * pxPerDp
+	padding := fitPadding * pxPerDp
+
+	lines := m.FitLines()
+	m.fit.texts = make([]TextLine, len(lines))
+	var w float32
+	for i, l := range lines {
+		m.fit.texts[i] = TextLine{Text: l, Pos: utils.LocalPos{X: padding, Y: padding + (float32(i)+.5)*lineHeight}}
+		w = max(w, utils.GetTextWidth(l, m.Font.Face, size, gtx))
+	}
+	m.fit.dim = utils.LocalDim{W: w + 2*padding, H: float32(len(lines))*lineHeight + 2*padding}
+}
+
+// formatFitIndex drops the leading zero, since the indices cannot exceed one
+func formatFitIndex(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	if v < 1 {
+		s = strings.TrimPrefix(s, "0")
+	}
+	return s
+}
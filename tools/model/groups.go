@@ -0,0 +1,132 @@
+package model
+
+import (
+	"fmt"
+	"main/utils"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gioui.org/layout"
+)
+
+// Estimate holds the values of a parameter within a single group
+type Estimate struct {
+	Est    float64    `json:"est,omitempty"`
+	PValue float64    `json:"p_value,omitempty"`
+	CI     [2]float64 `json:"ci,omitempty"`
+	Label  string     `json:"label,omitempty"`
+	Median float64    `json:"median,omitempty"` // posterior median of Bayesian models
+	Rhat   float64    `json:"rhat,omitempty"`   // potential scale reduction factor of Bayesian models
+	SE     float64    `json:"se,omitempty"`
+	Fixed  bool       `json:"fixed,omitempty"` // the parameter was fixed rather than estimated
+	Std    *Estimate  `json:"std,omitempty"`   // the standardized solution, if the input has one
+}
+
+// Panel describes where the diagram of a group is placed when several groups share one document
+type Panel struct {
+	Group  int
+	Title  string
+	Pos    utils.LocalPos // top-left corner of the panel in document coordinates, above the title
+	Offset utils.LocalPos // translation from model coordinates to document coordinates
+	Dim    utils.LocalDim
+}
+
+func (m *Model) IsMultiGroup() bool {
+	return len(m.Groups) > 1
+}
+
+// SetActiveGroup swaps the estimates of every connection for those of the given group.
+// Node positions are shared between groups, so only the estimate labels are recalculated.
+func (m *Model) SetActiveGroup(group int) {
+	m.ActiveGroup = group
+	for _, c := range m.Connections {
+		e, ok := c.GroupEstimates[group]
+		if !ok {
+			continue
+		}
+		c.Est = e.Est
+		c.PValue = e.PValue
+		c.CI = e.CI
+		c.Label = e.Label
+		c.Median = e.Median
+		c.Rhat = e.Rhat
+		c.SE = e.SE
+		c.Fixed = e.Fixed
+		c.Std = e.Std
+		c.EstWidth = 0 // force the label to be recalculated
+	}
+	for _, n := range m.Nodes {
+		if r2, ok := n.GroupR2[group]; ok {
+			n.R2 = r2
+		}
+		for _, t := range n.Thresholds {
+			if e, ok := t.GroupEstimates[group]; ok {
+				t.Est = e.Est
+			}
+		}
+	}
+	for _, p := range m.DefinedParams {
+		// lavaan defines parameters once for all groups, as group 0
+		e, ok := p.GroupEstimates[group]
+		if !ok {
+			e, ok = p.GroupEstimates[0]
+		}
+		if ok {
+			p.Est = e.Est
+			p.PValue = e.PValue
+			p.CI = e.CI
+			p.Std = e.Std
+		}
+	}
+	m.effects.valid = false
+}
+
+// NextGroup activates the group following the active one, wrapping around to the first group
+func (m *Model) NextGroup() {
+	if len(m.Groups) == 0 {
+		return
+	}
+	i := slices.Index(m.Groups, m.ActiveGroup)
+	m.SetActiveGroup(m.Groups[(i+1)%len(m.Groups)])
+}
+
+func (m *Model) GroupTitle(group int) string {
+	return fmt.Sprintf("Group %d", group)
+}
+
+// ActivateGroupForExport activates a group and recalculates the model without a window
+func ActivateGroupForExport(m *Model, group int) {
+	m.SetActiveGroup(group)
+	CalculateModel(m, layout.Context{})
+}
+
+// LayoutPanels places the diagram of each group side by side, leaving room for a title above each
+func LayoutPanels(m *Model, padding, titleHeight float32) (panels []Panel, docDim utils.LocalDim) {
+	x := float32(0)
+	for _, g := range m.Groups {
+		ActivateGroupForExport(m, g)
+		rect, dim := GetModelSize(m)
+
+		panels = append(panels, Panel{
+			Group:  g,
+			Title:  m.GroupTitle(g),
+			Pos:    utils.LocalPos{X: x + padding, Y: padding},
+			Offset: utils.LocalPos{X: x + padding - rect[0].X, Y: padding + titleHeight - rect[0].Y},
+			Dim:    dim,
+		})
+
+		x += dim.W + padding
+		docDim.H = max(docDim.H, dim.H+titleHeight)
+	}
+	docDim.W = x + padding
+	docDim.H += 2 * padding
+
+	return panels, docDim
+}
+
+// GroupFilePath derives the output path for a single group, e.g. "fig.svg" becomes "fig_group2.svg"
+func GroupFilePath(filePath string, group int) string {
+	ext := filepath.Ext(filePath)
+	return fmt.Sprintf("%s_group%d%s", strings.TrimSuffix(filePath, ext), group, ext)
+}
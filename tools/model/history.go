@@ -0,0 +1,61 @@
+package model
+
+const maxHistory = 100
+
+// History holds snapshots of the model for undo and redo.
+// Snapshots are restored in place so that pointers held by the editor stay valid.
+type History struct {
+	undo []*Model
+	redo []*Model
+}
+
+// Record saves the current state of the model. Call it before making a change
+func (h *History) Record(m *Model) {
+	h.undo = append(h.undo, m.Clone())
+	if len(h.undo) > maxHistory {
+		h.undo = h.undo[1:]
+	}
+	h.redo = nil
+}
+
+// Undo reverts the model to the last recorded state. Returns false if there is nothing to undo
+func (h *History) Undo(m *Model) bool {
+	if len(h.undo) == 0 {
+		return false
+	}
+
+	h.redo = append(h.redo, m.Clone())
+	restoreLayout(m, h.undo[len(h.undo)-1])
+	h.undo = h.undo[:len(h.undo)-1]
+	return true
+}
+
+// Redo reapplies the last undone change. Returns false if there is nothing to redo
+func (h *History) Redo(m *Model) bool {
+	if len(h.redo) == 0 {
+		return false
+	}
+
+	h.undo = append(h.undo, m.Clone())
+	restoreLayout(m, h.redo[len(h.redo)-1])
+	h.redo = h.redo[:len(h.redo)-1]
+	return true
+}
+
+// restoreLayout copies the editable layout of snapshot onto m.
+// Nodes and connections are never added or removed while editing, so they are matched by index
+func restoreLayout(m, snapshot *Model) {
+	for i, n := range m.Nodes {
+		n.Pos = snapshot.Nodes[i].Pos
+	}
+
+	for i, c := range m.Connections {
+		s := snapshot.Connections[i]
+		c.AlongLineProp = s.AlongLineProp
+		c.VarianceAngle = s.VarianceAngle
+		c.Curvature = s.Curvature
+	}
+
+	m.EffectsPos = snapshot.EffectsPos
+	m.FitPos = snapshot.FitPos
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"image/color"
+	"main/utils"
+	"slices"
+)
+
+type LabelDisplay int
+
+const (
+	LABELS_HIDDEN         LabelDisplay = iota
+	LABELS_WITH_ESTIMATES              // the label in front of the estimate, e.g. "a = 0.35*"
+	LABELS_ONLY                        // the label instead of the estimate
+)
+
+func (d LabelDisplay) String() string {
+	switch d {
+	case LABELS_WITH_ESTIMATES:
+		return "with the estimates"
+	case LABELS_ONLY:
+		return "instead of the estimates"
+	default:
+		return "hidden"
+	}
+}
+
+// constraintPalette colours the sets of equality-constrained parameters. The colours are told apart with the common
+// forms of colour blindness (Okabe & Ito)
+var constraintPalette = []color.NRGBA{
+	{R: 230, G: 159, B: 0, A: 255},
+	{R: 86, G: 180, B: 233, A: 255},
+	{R: 0, G: 158, B: 115, A: 255},
+	{R: 213, G: 94, B: 0, A: 255},
+	{R: 0, G: 114, B: 178, A: 255},
+	{R: 204, G: 121, B: 167, A: 255},
+}
+
+// NextLabelDisplay switches between hiding the parameter labels, showing them with the estimates and instead of them
+func (m *Model) NextLabelDisplay() {
+	m.LabelDisplay = (m.LabelDisplay + 1) % (LABELS_ONLY + 1)
+	for _, c := range m.Connections {
+		c.EstWidth = 0 // force the label to be recalculated
+	}
+}
+
+// NextCoeffDisplay switches between the ways of showing the estimates that suit the model
+func (m *Model) NextCoeffDisplay() {
+	displays := []utils.CoefficientDisplay{utils.STAR, utils.VALUE, utils.INTERVAL, utils.NONE}
+	if m.Bayesian {
+		displays = []utils.CoefficientDisplay{utils.POSTERIOR_MEAN, utils.POSTERIOR_MEDIAN, utils.POSTERIOR_INTERVAL, utils.POSTERIOR_RHAT, utils.NONE}
+	}
+	m.CoeffDisplay = displays[(slices.Index(displays, m.CoeffDisplay)+1)%len(displays)]
+
+	for _, c := range m.Connections {
+		c.EstWidth = 0 // force the label to be recalculated
+	}
+	m.effects.valid = false
+}
+
+// ShowsEstimate reports whether the estimate label of the connection is drawn
+func (m *Model) ShowsEstimate(c *Connection) bool {
+	return (c.UserDefined || m.ViewGenerated) && c.EstText != ""
+}
+
+// estimateText returns the text of the estimate label of the connection
+func (m *Model) estimateText(c *Connection) string {
+	est := m.formatSolution(Estimate{Est: c.Est, PValue: c.PValue, CI: c.CI, Median: c.Median, Rhat: c.Rhat, SE: c.SE, Fixed: c.Fixed, Std: c.Std})
+	switch {
+	case m.LabelDisplay == LABELS_HIDDEN:
+		return est
+	case c.Label == "" && m.LabelDisplay == LABELS_ONLY:
+		return ""
+	case c.Label == "":
+		return est
+	case m.LabelDisplay == LABELS_ONLY || est == "":
+		return c.Label
+	default:
+		return c.Label + " = " + est
+	}
+}
+
+// ConnectionColor returns the colour the connection is drawn in. Parameters that share a label are constrained to be
+// equal, and every set of them gets its own colour when constraints are highlighted
+func (m *Model) ConnectionColor(c *Connection) color.NRGBA {
+	if !m.HighlightConstraints || c.Label == "" {
+		return c.Col
+	}
+
+	i := slices.Index(m.constrainedLabels(), c.Label)
+	if i < 0 {
+		return c.Col
+	}
+	return constraintPalette[i%len(constraintPalette)]
+}
+
+// constrainedLabels returns the labels shared by more than one parameter in any group, sorted
+func (m *Model) constrainedLabels() []string {
+	count := make(map[string]int)
+	for _, c := range m.Connections {
+		if len(c.GroupEstimates) == 0 {
+			count[c.Label]++
+			continue
+		}
+		for _, e := range c.GroupEstimates {
+			count[e.Label]++
+		}
+	}
+
+	var res []string
+	for label, n := range count {
+		if label != "" && n > 1 {
+			res = append(res, label)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
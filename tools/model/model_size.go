@@ -0,0 +1,120 @@
+package model
+
+import (
+	"main/utils"
+
+	"gioui.org/layout"
+)
+
+// PrepareForExport returns a copy of the model whose dimensions are expressed in device-independent units.
+// The exporters switch between groups on the copy, which leaves the model of the editor as it is.
+func PrepareForExport(m *Model) *Model {
+	res := m.Clone()
+	if m.PxPerDp != 1.0 {
+		for _, n := range res.Nodes {
+			n.Dim = n.Dim.Div(m.PxPerDp)
+		}
+	}
+	CalculateModel(res, layout.Context{})
+
+	return res
+}
+
+func GetModelSize(m *Model) (rect [2]utils.LocalPos, dim utils.LocalDim) {
+	// first LocalPos in rect is the NW corner, second is the SE corner
+	// initialize rect as an existing position to ensure resultant rect is directly against the shapes
+	rect = [2]utils.LocalPos{m.Nodes[0].Pos, m.Nodes[0].Pos}
+	for _, n := range m.Nodes {
+		if n.Visible {
+			rect = [2]utils.LocalPos{n.Pos, n.Pos}
+			break
+		}
+	}
+
+	for _, n := range m.Nodes {
+		// hidden nodes and connections are not exported, so they should not take up space
+		if !n.Visible {
+			continue
+		}
+
+		halfW := m.ThresholdHalfWidth(n)
+		minX := n.Pos.X - halfW
+		maxX := n.Pos.X + halfW
+		minY := n.Pos.Y - n.Dim.H/2
+		maxY := n.Pos.Y + n.Dim.H/2 + m.ThresholdExtent(n)
+
+		if m.ShowsR2(n) && m.R2Display == R2_BADGE {
+			badge := m.R2BadgeRect(n)
+			minY = min(minY, badge[0].Y)
+			maxX = max(maxX, badge[1].X)
+		}
+
+		// handle x coords
+		if minX < rect[0].X {
+			rect[0].X = minX
+		}
+		if maxX > rect[1].X {
+			rect[1].X = maxX
+		}
+
+		// handle y coords
+		// (remember that lower ys are visually higher)
+		if minY < rect[0].Y {
+			rect[0].Y = minY
+		}
+		if maxY > rect[1].Y {
+			rect[1].Y = maxY
+		}
+	}
+
+	for _, c := range m.Connections {
+		if !c.UserDefined && !m.ViewGenerated {
+			continue
+		}
+
+		minX := c.EstPos.X - c.EstDim.W/2
+		maxX := c.EstPos.X + c.EstDim.W/2
+		minY := c.EstPos.Y - c.EstDim.H/2
+		maxY := c.EstPos.Y + c.EstDim.H/2
+
+		// handle x coords
+		if minX < rect[0].X {
+			rect[0].X = minX
+		}
+		if maxX > rect[1].X {
+			rect[1].X = maxX
+		}
+
+		// handle y coords
+		// (remember that lower ys are visually higher)
+		if minY < rect[0].Y {
+			rect[0].Y = minY
+		}
+		if maxY > rect[1].Y {
+			rect[1].Y = maxY
+		}
+	}
+
+	if m.ShowsEffects() {
+		effects := m.EffectsRect()
+		rect[0].X = min(rect[0].X, effects[0].X)
+		rect[0].Y = min(rect[0].Y, effects[0].Y)
+		rect[1].X = max(rect[1].X, effects[1].X)
+		rect[1].Y = max(rect[1].Y, effects[1].Y)
+	}
+
+	if m.ShowsFit() {
+		fit := m.FitRect()
+		rect[0].X = min(rect[0].X, fit[0].X)
+		rect[0].Y = min(rect[0].Y, fit[0].Y)
+		rect[1].X = max(rect[1].X, fit[1].X)
+		rect[1].Y = max(rect[1].Y, fit[1].Y)
+	}
+
+	dim = utils.LocalDim{
+		W: utils.Abs32(rect[1].X - rect[0].X),
+		H: utils.Abs32(rect[1].Y - rect[0].Y),
+	}
+
+	return
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"main/utils"
+	"math"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+)
+
+type R2Display int
+
+const (
+	R2_HIDDEN R2Display = iota
+	R2_INSIDE           // a second line under the name of the node
+	R2_BADGE            // a small label on the upper right edge of the node
+)
+
+func (d R2Display) String() string {
+	switch d {
+	case R2_INSIDE:
+		return "inside the nodes"
+	case R2_BADGE:
+		return "badges"
+	default:
+		return "hidden"
+	}
+}
+
+const (
+	r2BadgeInset     float32 = 3 // space between the badge text and its outline
+	r2BadgeOverlap   float32 = 6 // how far the badge reaches over the node
+	R2BadgeThickness float32 = 1
+)
+
+// NextR2Display switches between hiding the variance explained, showing it inside the nodes and as badges
+func (m *Model) NextR2Display() {
+	m.R2Display = (m.R2Display + 1) % (R2_BADGE + 1)
+}
+
+// HasR2 reports whether the variance of the node is explained by the model, i.e. whether it is endogenous
+func (n *Node) HasR2() bool {
+	return len(n.GroupR2) > 0
+}
+
+// ShowsR2 reports whether the variance explained is drawn for the node
+func (m *Model) ShowsR2(n *Node) bool {
+	return m.R2Display != R2_HIDDEN && n.Visible && n.HasR2()
+}
+
+// R2Text formats the variance explained of the node, e.g. "R² = .45". The leading zero is dropped, since it cannot
+// exceed one
+func (m *Model) R2Text(n *Node) string {
+	s := strconv.FormatFloat(n.R2, 'f', m.EstimatePrecision(), 64)
+	if n.R2 < 1 {
+		s = strings.Replace(s, "0.", ".", 1)
+	}
+	return "R² = " + s
+}
+
+// R2FontSize is the size of the variance explained, matching the estimate labels
+func (m *Model) R2FontSize() float32 {
+	return m.Font.Size - 2
+}
+
+// R2Label returns the text of the variance explained of the node and the position of its center
+func (m *Model) R2Label(n *Node) (string, utils.LocalPos) {
+	txt := m.R2Text(n)
+	if m.R2Display == R2_INSIDE {
+		// the name and the line below it are centered on the node together
+		return txt, utils.LocalPos{X: n.Pos.X, Y: n.Pos.Y + m.Font.Size/2}
+	}
+
+	// the lower left corner of the badge covers the upper right corner of the node
+	half := m.r2BadgeHalfDim(txt)
+	return txt, m.r2Corner(n).Add(utils.LocalPos{X: half.W - r2BadgeOverlap, Y: r2BadgeOverlap - half.H})
+}
+
+// R2BadgeRect returns the NW and SE corners of the outline of the badge
+func (m *Model) R2BadgeRect(n *Node) [2]utils.LocalPos {
+	txt, center := m.R2Label(n)
+	half := m.r2BadgeHalfDim(txt)
+	return [2]utils.LocalPos{center.SubDim(half), center.AddDim(half)}
+}
+
+// r2Corner returns the upper right corner of the outline of the node
+func (m *Model) r2Corner(n *Node) utils.LocalPos {
+	var corner utils.LocalPos
+	switch n.Class {
+	case LATENT:
+		corner = utils.LocalPos{X: n.Pos.X + n.Dim.W/2*math.Sqrt2/2, Y: n.Pos.Y - n.Dim.H/2*math.Sqrt2/2}
+	case COMPOSITE:
+		corner = utils.HexagonVertices(n.Pos, n.Dim)[2]
+	default:
+		corner = utils.LocalPos{X: n.Pos.X + n.Dim.W/2, Y: n.Pos.Y - n.Dim.H/2}
+	}
+	return corner
+}
+
+func (m *Model) r2BadgeHalfDim(txt string) utils.LocalDim {
+	return utils.LocalDim{
+		W: utils.GetTextWidth(txt, m.Font.Face, m.R2FontSize(), layout.Context{})/2 + r2BadgeInset,
+		H: m.R2FontSize()/2 + r2BadgeInset,
+	}
+}
+
+// NodeTextShift is how far the name of the node is moved down from its center, making room for the variance explained
+// inside the node
+func (m *Model) NodeTextShift(n *Node) float32 {
+	if m.R2Display != R2_INSIDE || !m.ShowsR2(n) {
+		return 0
+	}
+	return -m.r2LineHeight() / 2
+}
+
+// r2InsideDim returns how much wider and taller the node grows to fit the variance explained under its name
+func (m *Model) r2InsideDim(n *Node, textWidth float32, gtx layout.Context) (width, height float32) {
+	if m.R2Display != R2_INSIDE || !m.ShowsR2(n) {
+		return 0, 0
+	}
+	return max(0, utils.GetTextWidth(m.R2Text(n), m.Font.Face, m.R2FontSize(), gtx)-textWidth), m.r2LineHeight()
+}
+
+func (m *Model) r2LineHeight() float32 {
+	return m.R2FontSize() * 1.5
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+	"main/utils"
+	"slices"
+	"strings"
+)
+
+type Solution int
+
+const (
+	SOLUTION_UNSTANDARDIZED    Solution = iota
+	SOLUTION_STANDARDIZED               // lavaan's std.all
+	SOLUTION_BOTH                       // the unstandardized estimate followed by the standardized one, e.g. "0.42 (0.31)"
+	SOLUTION_UNSTANDARDIZED_SE          // the unstandardized estimate followed by its standard error
+	SOLUTION_STANDARDIZED_SE            // the standardized estimate followed by its standard error
+)
+
+func ParseSolution(s string) (Solution, error) {
+	switch strings.ToLower(s) {
+	case "unstandardized":
+		return SOLUTION_UNSTANDARDIZED, nil
+	case "standardized":
+		return SOLUTION_STANDARDIZED, nil
+	case "both":
+		return SOLUTION_BOTH, nil
+	case "unstandardized-se":
+		return SOLUTION_UNSTANDARDIZED_SE, nil
+	case "standardized-se":
+		return SOLUTION_STANDARDIZED_SE, nil
+	}
+	return 0, fmt.Errorf("unknown solution %q (expected unstandardized, standardized, both, unstandardized-se or standardized-se)", s)
+}
+
+func (s Solution) String() string {
+	switch s {
+	case SOLUTION_STANDARDIZED:
+		return "standardized"
+	case SOLUTION_BOTH:
+		return "unstandardized (standardized)"
+	case SOLUTION_UNSTANDARDIZED_SE:
+		return "unstandardized (SE)"
+	case SOLUTION_STANDARDIZED_SE:
+		return "standardized (SE)"
+	default:
+		return "unstandardized"
+	}
+}
+
+// IsStandardized reports whether the standardized estimate is the one shown first
+func (s Solution) IsStandardized() bool {
+	return s == SOLUTION_STANDARDIZED || s == SOLUTION_STANDARDIZED_SE
+}
+
+// NextSolution switches between the solutions the input carries. Posterior summaries only exist for the unstandardized
+// solution of Bayesian models
+func (m *Model) NextSolution() {
+	solutions := m.Solutions()
+	m.Solution = solutions[(slices.Index(solutions, m.Solution)+1)%len(solutions)]
+	m.invalidateEstimates()
+}
+
+// Solutions returns the solutions the estimates can be shown in
+func (m *Model) Solutions() []Solution {
+	var hasStd, hasSE, hasStdSE bool
+	check := func(e Estimate) {
+		hasSE = hasSE || e.SE != 0
+		if e.Std != nil {
+			hasStd = true
+			hasStdSE = hasStdSE || e.Std.SE != 0
+		}
+	}
+	for _, c := range m.Connections {
+		for _, e := range c.GroupEstimates {
+			check(e)
+		}
+	}
+	for _, p := range m.DefinedParams {
+		for _, e := range p.GroupEstimates {
+			check(e)
+		}
+	}
+	hasStd = hasStd && !m.Bayesian
+
+	res := []Solution{SOLUTION_UNSTANDARDIZED}
+	if hasStd {
+		res = append(res, SOLUTION_STANDARDIZED, SOLUTION_BOTH)
+	}
+	if hasSE {
+		res = append(res, SOLUTION_UNSTANDARDIZED_SE)
+	}
+	if hasStd && hasStdSE {
+		res = append(res, SOLUTION_STANDARDIZED_SE)
+	}
+	return res
+}
+
+// shownEstimate returns the estimate in the solution shown first. Parameters without a standardized estimate keep
+// their unstandardized one
+func (m *Model) shownEstimate(e Estimate) Estimate {
+	if m.Solution.IsStandardized() && e.Std != nil {
+		return *e.Std
+	}
+	return e
+}
+
+// solutionSuffixes are added to the template of the way of showing the estimates, e.g. "{est}{stars} ({std})" gives
+// "0.42* (0.31)" with both solutions
+var solutionSuffixes = map[Solution]string{
+	SOLUTION_BOTH:              " ({std})",
+	SOLUTION_UNSTANDARDIZED_SE: " ({se})",
+	SOLUTION_STANDARDIZED_SE:   " ({se})",
+}
+
+// formatSolution formats the estimate in the chosen template, or else in the way of showing the estimates and the
+// solution
+func (m *Model) formatSolution(e Estimate) string {
+	if m.CoeffDisplay == utils.NONE {
+		return ""
+	}
+	if m.LabelTemplate != "" {
+		return m.formatTemplate(e, m.LabelTemplate)
+	}
+	return m.formatTemplate(e, m.solutionTemplate(e))
+}
+
+// solutionTemplate returns the preset template of the estimate. Parameters without a standardized estimate and fixed
+// parameters, which have no standard error, are not followed by them
+func (m *Model) solutionTemplate(e Estimate) string {
+	template := displayTemplates[m.CoeffDisplay]
+	switch {
+	case m.Solution == SOLUTION_BOTH && e.Std == nil:
+	case m.Solution != SOLUTION_BOTH && isFixed(m.shownEstimate(e)):
+	default:
+		template += solutionSuffixes[m.Solution]
+	}
+	return template
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"fmt"
+	"main/utils"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPrecision = 2 // decimal places of layouts saved before the precision could be chosen
+	MaxPrecision     = 4
+)
+
+// labelTemplates are the templates the editor switches between. The empty template formats the labels in the style
+// chosen with NextCoeffDisplay
+var labelTemplates = []string{
+	"",
+	"{est}{stars} [{lo}, {hi}]",
+	"{est} ({se})",
+	"{est}{stars} ({se})",
+	"{est} ({std})",
+}
+
+// displayTemplates are the templates of the ways of showing the estimates that NextCoeffDisplay switches between
+var displayTemplates = map[utils.CoefficientDisplay]string{
+	utils.VALUE:              "{est}",
+	utils.INTERVAL:           "[{lo}, {hi}]",
+	utils.STAR:               "{est}{stars}",
+	utils.POSTERIOR_MEAN:     "{est}{stars}",
+	utils.POSTERIOR_MEDIAN:   "{median}{stars}",
+	utils.POSTERIOR_INTERVAL: "{est} [{lo}, {hi}]",
+	utils.POSTERIOR_RHAT:     "{est} (Rhat {rhat})",
+}
+
+// templatePlaceholders are the values a label template can hold. {stars} marks posterior estimates whose interval
+// excludes zero instead
+var templatePlaceholders = []string{"est", "stars", "lo", "hi", "se", "p", "std", "median", "rhat"}
+
+// ParseLabelTemplate checks that the braces of a template such as "{est}{stars} [{lo}, {hi}]" enclose known
+// placeholders
+func ParseLabelTemplate(template string) error {
+	rest := template
+	for {
+		open := strings.IndexByte(rest, '{')
+		if closing := strings.IndexByte(rest, '}'); closing >= 0 && (open < 0 || closing < open) {
+			return fmt.Errorf("unopened } in template %q", template)
+		}
+		if open < 0 {
+			return nil
+		}
+
+		length := strings.IndexByte(rest[open:], '}')
+		if length < 0 {
+			return fmt.Errorf("unclosed { in template %q", template)
+		}
+		if name := rest[open+1 : open+length]; !slices.Contains(templatePlaceholders, name) {
+			return fmt.Errorf("unknown placeholder {%s} in template %q (expected one of {%s})", name, template, strings.Join(templatePlaceholders, "}, {"))
+		}
+		rest = rest[open+length+1:]
+	}
+}
+
+// EstimatePrecision returns the number of decimal places of the estimates
+func (m *Model) EstimatePrecision() int {
+	if m.Precision == nil {
+		return defaultPrecision
+	}
+	return *m.Precision
+}
+
+// SetLabelFormat sets the template and the number of decimal places of the estimate labels. A negative precision keeps
+// the current one
+func (m *Model) SetLabelFormat(template string, precision int) {
+	m.LabelTemplate = template
+	if precision >= 0 {
+		m.setPrecision(precision)
+	}
+	m.invalidateEstimates()
+}
+
+// NextLabelTemplate switches between the templates whose values the input has, starting over from a custom template
+func (m *Model) NextLabelTemplate() {
+	solutions := m.Solutions()
+	var templates []string
+	for _, t := range labelTemplates {
+		switch {
+		case strings.Contains(t, "{std}") && !slices.Contains(solutions, SOLUTION_BOTH):
+		case strings.Contains(t, "{se}") && !slices.Contains(solutions, SOLUTION_UNSTANDARDIZED_SE):
+		default:
+			templates = append(templates, t)
+		}
+	}
+
+	m.LabelTemplate = templates[(slices.Index(templates, m.LabelTemplate)+1)%len(templates)]
+	m.invalidateEstimates()
+}
+
+// NextPrecision switches between zero and MaxPrecision decimal places
+func (m *Model) NextPrecision() {
+	m.setPrecision((m.EstimatePrecision() + 1) % (MaxPrecision + 1))
+	m.invalidateEstimates()
+}
+
+// setPrecision replaces rather than changes the precision, which clones of the model share
+func (m *Model) setPrecision(precision int) {
+	m.Precision = &precision
+}
+
+func (m *Model) invalidateEstimates() {
+	for _, c := range m.Connections {
+		c.EstWidth = 0 // force the label to be recalculated
+	}
+	m.effects.valid = false
+}
+
+// formatTemplate fills in a label template with the estimate in the solution shown first. A parameter without a
+// standardized solution leaves {std} empty
+func (m *Model) formatTemplate(e Estimate, template string) string {
+	shown := m.shownEstimate(e)
+	precision := m.EstimatePrecision()
+	num := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', precision, 64)
+	}
+
+	var stars, p string
+	switch {
+	case isFixed(shown):
+		// fixed parameters are not tested
+	case m.Bayesian:
+		stars = utils.ExcludesZeroMarker(shown.CI)
+	default:
+		stars = utils.SignificanceStars(shown.PValue)
+		p = formatPValue(shown.PValue)
+	}
+	var std string
+	if e.Std != nil {
+		std = num(e.Std.Est)
+	}
+
+	return strings.NewReplacer(
+		"{est}", num(shown.Est),
+		"{stars}", stars,
+		"{lo}", num(shown.CI[0]),
+		"{hi}", num(shown.CI[1]),
+		"{se}", num(shown.SE),
+		"{p}", p,
+		"{std}", std,
+		"{median}", num(shown.Median),
+		"{rhat}", strconv.FormatFloat(shown.Rhat, 'f', 2, 64),
+	).Replace(template)
+}
+
+// isFixed reports whether the parameter was fixed rather than estimated. Inputs that do not mark fixed parameters give
+// estimated ones a standard error or a p-value
+func isFixed(e Estimate) bool {
+	return e.Fixed || e.SE == 0 && e.PValue == 0
+}
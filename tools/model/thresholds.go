@@ -0,0 +1,119 @@
+package model
+
+import (
+	"main/utils"
+	"maps"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+)
+
+type ThresholdDisplay int
+
+const (
+	THRESHOLDS_HIDDEN ThresholdDisplay = iota
+	THRESHOLDS_TICKS                   // tick marks on a scale below the node
+	THRESHOLDS_LIST                    // the estimates listed below the node
+)
+
+func (d ThresholdDisplay) String() string {
+	switch d {
+	case THRESHOLDS_TICKS:
+		return "tick marks"
+	case THRESHOLDS_LIST:
+		return "list"
+	default:
+		return "hidden"
+	}
+}
+
+const (
+	thresholdGap       float32 = 6 // space between the node and its thresholds
+	thresholdTickLen   float32 = 8
+	thresholdScale     float64 = 3 // the scale runs from -3 to 3, which covers the usual range of probit thresholds
+	ThresholdThickness float32 = 1
+)
+
+// Threshold is a threshold of an ordinal indicator, e.g. "t1" of lavaan's "x1 | t1"
+type Threshold struct {
+	Name           string           `json:"name"`
+	Est            float64          `json:"est,omitempty"`
+	GroupEstimates map[int]Estimate `json:"group_estimates,omitempty"`
+}
+
+func (t *Threshold) Clone() *Threshold {
+	return &Threshold{
+		Name:           t.Name,
+		Est:            t.Est,
+		GroupEstimates: maps.Clone(t.GroupEstimates),
+	}
+}
+
+// NextThresholdDisplay switches between hiding thresholds, tick marks and a list
+func (m *Model) NextThresholdDisplay() {
+	m.ThresholdDisplay = (m.ThresholdDisplay + 1) % (THRESHOLDS_LIST + 1)
+}
+
+// ShowsThresholds reports whether thresholds are drawn below the node
+func (m *Model) ShowsThresholds(n *Node) bool {
+	return m.ThresholdDisplay != THRESHOLDS_HIDDEN && n.Visible && len(n.Thresholds) > 0
+}
+
+// ThresholdExtent is how far below the bottom of the node its thresholds reach
+func (m *Model) ThresholdExtent(n *Node) float32 {
+	if !m.ShowsThresholds(n) {
+		return 0
+	}
+	if m.ThresholdDisplay == THRESHOLDS_TICKS {
+		return thresholdGap + thresholdTickLen
+	}
+	return thresholdGap + m.thresholdTextHeight()
+}
+
+// ThresholdTicks returns the lines of the tick mark display: a scale as wide as the node followed by one tick per
+// threshold. Thresholds beyond the ends of the scale are drawn at its ends
+func ThresholdTicks(n *Node) [][2]utils.LocalPos {
+	y := n.Pos.Y + n.Dim.H/2 + thresholdGap + thresholdTickLen/2
+	left := n.Pos.X - n.Dim.W/2
+	right := n.Pos.X + n.Dim.W/2
+
+	lines := [][2]utils.LocalPos{{{X: left, Y: y}, {X: right, Y: y}}}
+	for _, t := range n.Thresholds {
+		prop := (max(-thresholdScale, min(thresholdScale, t.Est)) + thresholdScale) / (2 * thresholdScale)
+		x := left + float32(prop)*n.Dim.W
+		lines = append(lines, [2]utils.LocalPos{{X: x, Y: y - thresholdTickLen/2}, {X: x, Y: y + thresholdTickLen/2}})
+	}
+
+	return lines
+}
+
+// ThresholdHalfWidth is how far the thresholds reach to either side of the center of the node
+func (m *Model) ThresholdHalfWidth(n *Node) float32 {
+	if !m.ShowsThresholds(n) || m.ThresholdDisplay == THRESHOLDS_TICKS {
+		return n.Dim.W / 2
+	}
+	txt, _ := m.ThresholdLabel(n)
+	return max(n.Dim.W/2, utils.GetTextWidth(txt, m.Font.Face, m.ThresholdFontSize(), layout.Context{})/2)
+}
+
+// ThresholdLabel returns the text of the list display and the position of its center
+func (m *Model) ThresholdLabel(n *Node) (string, utils.LocalPos) {
+	values := make([]string, len(n.Thresholds))
+	for i, t := range n.Thresholds {
+		values[i] = strconv.FormatFloat(t.Est, 'f', m.EstimatePrecision(), 64)
+	}
+
+	center := utils.LocalPos{X: n.Pos.X, Y: n.Pos.Y + n.Dim.H/2 + thresholdGap + m.thresholdTextHeight()/2}
+	// the values are the cut points between neighbouring categories
+	return strings.Join(values, " | "), center
+}
+
+// ThresholdFontSize is the size of the list display, matching the estimate labels
+func (m *Model) ThresholdFontSize() float32 {
+	return m.Font.Size - 2
+}
+
+func (m *Model) thresholdTextHeight() float32 {
+	return m.ThresholdFontSize() * 1.5
+}
@@ -42,6 +42,47 @@ func DrawEllipse(pdf *gofpdf.Fpdf, pos utils.LocalPos, dim utils.LocalDim, col c
 	pdf.Ellipse(float64(cx), float64(cy), float64(rx), float64(ry), 0, "D")
 }
 
+func DrawTriangle(pdf *gofpdf.Fpdf, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Calculate vertices of an upward-pointing triangle from the top-left corner
+	vertices := utils.TriangleVertices(pos.AddDim(dim.Div(2)), dim)
+	points := make([]gofpdf.PointType, len(vertices))
+	for i, v := range vertices {
+		points[i] = gofpdf.PointType{X: float64(v.X), Y: float64(v.Y)}
+	}
+
+	// Set fill color
+	pdf.SetFillColor(int(col.R), int(col.G), int(col.B))
+
+	// Draw filled triangle
+	pdf.Polygon(points, "F")
+
+	// Draw outline
+	pdf.SetLineWidth(float64(thickness))
+	pdf.SetDrawColor(0, 0, 0) // Black outline
+	pdf.SetLineJoinStyle("miter")
+	pdf.Polygon(points, "D")
+}
+
+func DrawHexagon(pdf *gofpdf.Fpdf, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	vertices := utils.HexagonVertices(pos.AddDim(dim.Div(2)), dim)
+	points := make([]gofpdf.PointType, len(vertices))
+	for i, v := range vertices {
+		points[i] = gofpdf.PointType{X: float64(v.X), Y: float64(v.Y)}
+	}
+
+	// Set fill color
+	pdf.SetFillColor(int(col.R), int(col.G), int(col.B))
+
+	// Draw filled hexagon
+	pdf.Polygon(points, "F")
+
+	// Draw outline
+	pdf.SetLineWidth(float64(thickness))
+	pdf.SetDrawColor(0, 0, 0) // Black outline
+	pdf.SetLineJoinStyle("miter")
+	pdf.Polygon(points, "D")
+}
+
 func DrawArrowLine(pdf *gofpdf.Fpdf, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
 	angle := utils.GetAngleLoc(posA, posB)
 	arrowSize := thickness * 5
@@ -161,6 +202,15 @@ func DrawArrowArc(pdf *gofpdf.Fpdf, posA, posB, refPoint utils.LocalPos, radius
 	DrawArrowHead(pdf, utils.ToLocalPos(arrowPosB), angleTangentB, float32(arrowSize), col)
 }
 
+// DrawSymbol draws text in the symbol font, where e.g. "c" is the Greek chi, and returns its width
+func DrawSymbol(pdf *gofpdf.Fpdf, pos utils.LocalPos, txt string, size, ppRatio float32) float32 {
+	pdf.SetFont("Symbol", "", float64(size*ppRatio))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(float64(pos.X), float64(pos.Y))
+	pdf.Cell(0, 0, txt)
+	return float32(pdf.GetStringWidth(txt))
+}
+
 func DrawText(pdf *gofpdf.Fpdf, pos utils.LocalPos, txt string, fontFamily string, bold bool, size, ppRatio float32) {
 	styleStr := ""
 	if bold {
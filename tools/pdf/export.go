@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"gioui.org/layout"
 	"github.com/jung-kurt/gofpdf"
@@ -25,35 +26,76 @@ var pdfFontFS embed.FS
 
 func ExportModel(m *model.Model, filePath string) {
 	// check for different PxPerDp. If not one, copy the model and apply a transformation
-	var mAdj *model.Model
-	if m.PxPerDp == 1.0 {
-		mAdj = m
-	} else {
-		mAdj = transformModel(m)
-		model.CalculateModel(mAdj, layout.Context{})
-	}
-
-	rect, localDim := GetModelSize(mAdj)
-
-	pageWidth := localDim.W*ppRatio + 2*docPadding
-	pageHeight := localDim.H*ppRatio + 2*docPadding
+	mAdj := model.PrepareForExport(m)
 
 	fontDir := createTempFontDir()
 	defer os.RemoveAll(fontDir)
 
 	pdf := gofpdf.NewCustom(&gofpdf.InitType{
 		UnitStr:    "pt",
-		Size:       gofpdf.SizeType{Wd: float64(pageWidth), Ht: float64(pageHeight)},
 		FontDirStr: fontDir,
 	})
 	pdf.SetAutoPageBreak(false, 0) // required to avoid automatic page breaks at different text sizes
 	utils.LoadPdfFonts(pdf)
-	pdf.AddPage()
+
+	switch {
+	case !mAdj.IsMultiGroup():
+		addModelPage(pdf, m, mAdj)
+	case mAdj.GroupPanels:
+		// place every group side by side on a single page
+		activeGroup := mAdj.ActiveGroup
+		titleHeight := m.Font.Size * 2
+		panels, docDim := model.LayoutPanels(mAdj, docPadding, titleHeight)
+
+		pdf.AddPageFormat("P", gofpdf.SizeType{Wd: float64(docDim.W * ppRatio), Ht: float64(docDim.H * ppRatio)})
+		for _, p := range panels {
+			model.ActivateGroupForExport(mAdj, p.Group)
+
+			titleWidth := utils.GetTextWidth(p.Title, m.Font.Face, m.Font.Size*ppRatio, layout.Context{})
+			titlePos := utils.LocalPos{
+				X: (p.Pos.X+p.Dim.W/2)*ppRatio - titleWidth/2 - textAdj,
+				Y: (p.Pos.Y + titleHeight/2) * ppRatio,
+			}
+			DrawText(pdf, titlePos, p.Title, m.Font.Family, true, m.Font.Size, ppRatio)
+
+			drawModel(pdf, m, mAdj, p.Offset.X, p.Offset.Y)
+		}
+		model.ActivateGroupForExport(mAdj, activeGroup)
+	default:
+		// one page per group
+		activeGroup := mAdj.ActiveGroup
+		for _, g := range mAdj.Groups {
+			model.ActivateGroupForExport(mAdj, g)
+			addModelPage(pdf, m, mAdj)
+		}
+		model.ActivateGroupForExport(mAdj, activeGroup)
+	}
+
+	// export
+	err := pdf.OutputFileAndClose(filePath)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// addModelPage adds a page sized to fit the model and draws the model on it
+func addModelPage(pdf *gofpdf.Fpdf, m, mAdj *model.Model) {
+	rect, localDim := model.GetModelSize(mAdj)
+
+	pageWidth := localDim.W*ppRatio + 2*docPadding
+	pageHeight := localDim.H*ppRatio + 2*docPadding
+
+	pdf.AddPageFormat("P", gofpdf.SizeType{Wd: float64(pageWidth), Ht: float64(pageHeight)})
 
 	// Offset to translate model coordinates to page coordinates
 	offsetX := docPadding - rect[0].X
 	offsetY := docPadding - rect[0].Y
 
+	drawModel(pdf, m, mAdj, offsetX, offsetY)
+}
+
+// drawModel draws the diagram onto the current page. m supplies the font settings of the original model
+func drawModel(pdf *gofpdf.Fpdf, m, mAdj *model.Model, offsetX, offsetY float32) {
 	for _, n := range mAdj.Nodes {
 		if !n.Visible {
 			continue
@@ -73,15 +115,26 @@ func ExportModel(m *model.Model, filePath string) {
 		case model.LATENT:
 			DrawEllipse(pdf, adjPos, adjDim, n.Col, n.Thickness*ppRatio*.5)
 		case model.INTERCEPT:
-			// todo: handle intercepts
+			DrawTriangle(pdf, adjPos, adjDim, n.Col, n.Thickness*ppRatio*.5)
+		case model.COMPOSITE:
+			DrawHexagon(pdf, adjPos, adjDim, n.Col, n.Thickness*ppRatio*.5)
 		}
 
 		textPos := utils.LocalPos{
 			X: adjPos.X - textAdj + n.Padding*ppRatio,
 			Y: adjPos.Y + adjDim.H/2,
 		}
+		if n.Class == model.INTERCEPT {
+			// center the text on the centroid of the triangle
+			textPos.Y += adjDim.H / 6
+		}
+		textPos.Y += mAdj.NodeTextShift(n) * ppRatio
 
 		DrawText(pdf, textPos, n.Text, m.Font.Family, n.Bold, m.Font.Size, ppRatio)
+
+		if mAdj.ShowsThresholds(n) {
+			drawThresholds(pdf, m, mAdj, n, offsetX, offsetY)
+		}
 	}
 
 	for _, c := range mAdj.Connections {
@@ -97,21 +150,25 @@ func ExportModel(m *model.Model, filePath string) {
 
 		switch c.Type {
 		case model.STRAIGHT:
-			DrawArrowLine(pdf, originPos, destPos, c.Col, c.Thickness*ppRatio)
+			DrawArrowLine(pdf, originPos, destPos, mAdj.ConnectionColor(c), c.Thickness*ppRatio)
 		case model.CURVED:
-			DrawArrowCurve(pdf, originPos, destPos, c.Col, c.Thickness*ppRatio, c.Curvature)
+			DrawArrowCurve(pdf, originPos, destPos, mAdj.ConnectionColor(c), c.Thickness*ppRatio, c.Curvature)
 		case model.CIRCULAR:
 			refPos := utils.LocalPos{
 				X: (c.RefPos.X + offsetX) * ppRatio,
 				Y: (c.RefPos.Y + offsetY) * ppRatio,
 			}
 			var radius float32 = 20
-			DrawArrowArc(pdf, originPos, destPos, refPos, radius*ppRatio, c.Col, c.Thickness*ppRatio)
+			DrawArrowArc(pdf, originPos, destPos, refPos, radius*ppRatio, mAdj.ConnectionColor(c), c.Thickness*ppRatio)
 		}
 	}
 
 	// draw estimate labels after all the connections to ensure proper layering
 	for _, c := range mAdj.Connections {
+		if !mAdj.ShowsEstimate(c) {
+			continue
+		}
+
 		textWidth := utils.GetTextWidth(c.EstText, m.Font.Face, (m.Font.Size-2)*ppRatio, layout.Context{}) + (c.EstPadding * ppRatio)
 		textPos := utils.LocalPos{
 			X: (c.EstPos.X+offsetX)*ppRatio - textWidth/2 - textAdj,
@@ -129,72 +186,102 @@ func ExportModel(m *model.Model, filePath string) {
 		DrawText(pdf, textPos, c.EstText, m.Font.Family, false, m.Font.Size-2, ppRatio)
 	}
 
-	// export
-	err := pdf.OutputFileAndClose(filePath)
-	if err != nil {
-		panic(err)
+	for _, n := range mAdj.Nodes {
+		if mAdj.ShowsR2(n) {
+			drawR2(pdf, m, mAdj, n, offsetX, offsetY)
+		}
 	}
-}
 
-func GetModelSize(m *model.Model) (rect [2]utils.LocalPos, dim utils.LocalDim) {
-	// first LocalPos in rect is the NW corner, second is the SE corner
-	// initialize rect as an existing position to ensure resultant rect is directly against the shapes
-	rect = [2]utils.LocalPos{m.Nodes[0].Pos, m.Nodes[0].Pos}
+	if mAdj.ShowsEffects() {
+		drawEffects(pdf, m, mAdj, offsetX, offsetY)
+	}
 
-	for _, n := range m.Nodes {
-		minX := n.Pos.X - n.Dim.W/2
-		maxX := n.Pos.X + n.Dim.W/2
-		minY := n.Pos.Y - n.Dim.H/2
-		maxY := n.Pos.Y + n.Dim.H/2
+	if mAdj.ShowsFit() {
+		drawFit(pdf, m, mAdj, offsetX, offsetY)
+	}
+}
 
-		// handle x coords
-		if minX < rect[0].X {
-			rect[0].X = minX
-		}
-		if maxX > rect[1].X {
-			rect[1].X = maxX
-		}
+// drawThresholds draws the thresholds of an ordinal node below it, as tick marks or as a list
+func drawThresholds(pdf *gofpdf.Fpdf, m, mAdj *model.Model, n *model.Node, offsetX, offsetY float32) {
+	toPage := func(pos utils.LocalPos) utils.LocalPos {
+		return utils.LocalPos{X: (pos.X + offsetX) * ppRatio, Y: (pos.Y + offsetY) * ppRatio}
+	}
 
-		// handle y coords
-		// (remember that lower ys are visually higher)
-		if minY < rect[0].Y {
-			rect[0].Y = minY
-		}
-		if maxY > rect[1].Y {
-			rect[1].Y = maxY
+	switch mAdj.ThresholdDisplay {
+	case model.THRESHOLDS_TICKS:
+		for _, l := range model.ThresholdTicks(n) {
+			DrawLine(pdf, toPage(l[0]), toPage(l[1]), color.NRGBA{A: 255}, model.ThresholdThickness*ppRatio)
 		}
+	case model.THRESHOLDS_LIST:
+		txt, center := mAdj.ThresholdLabel(n)
+		size := mAdj.ThresholdFontSize()
+		textWidth := utils.GetTextWidth(txt, m.Font.Face, size*ppRatio, layout.Context{})
+		textPos := toPage(center)
+		textPos.X -= textWidth/2 + textAdj
+		DrawText(pdf, textPos, txt, m.Font.Family, false, size, ppRatio)
 	}
+}
 
-	for _, c := range m.Connections {
-		minX := c.EstPos.X - c.EstDim.W/2
-		maxX := c.EstPos.X + c.EstDim.W/2
-		minY := c.EstPos.Y - c.EstDim.H/2
-		maxY := c.EstPos.Y + c.EstDim.H/2
+// drawEffects draws the block of defined parameters
+func drawEffects(pdf *gofpdf.Fpdf, m, mAdj *model.Model, offsetX, offsetY float32) {
+	toPage := func(pos utils.LocalPos) utils.LocalPos {
+		return utils.LocalPos{X: (pos.X + offsetX) * ppRatio, Y: (pos.Y + offsetY) * ppRatio}
+	}
 
-		// handle x coords
-		if minX < rect[0].X {
-			rect[0].X = minX
-		}
-		if maxX > rect[1].X {
-			rect[1].X = maxX
-		}
+	for _, l := range mAdj.EffectsRules() {
+		DrawLine(pdf, toPage(l[0]), toPage(l[1]), color.NRGBA{A: 255}, model.EffectsRuleThickness*ppRatio)
+	}
 
-		// handle y coords
-		// (remember that lower ys are visually higher)
-		if minY < rect[0].Y {
-			rect[0].Y = minY
-		}
-		if maxY > rect[1].Y {
-			rect[1].Y = maxY
+	for _, t := range mAdj.EffectsTexts() {
+		textPos := toPage(t.Pos)
+		textPos.X -= textAdj
+		DrawText(pdf, textPos, t.Text, m.Font.Family, false, mAdj.EffectsFontSize(), ppRatio)
+	}
+}
+
+// drawFit draws the box of fit indices
+func drawFit(pdf *gofpdf.Fpdf, m, mAdj *model.Model, offsetX, offsetY float32) {
+	toPage := func(pos utils.LocalPos) utils.LocalPos {
+		return utils.LocalPos{X: (pos.X + offsetX) * ppRatio, Y: (pos.Y + offsetY) * ppRatio}
+	}
+
+	rect := mAdj.FitRect()
+	dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+	DrawRect(pdf, toPage(rect[0]), dim.Mul(ppRatio), color.NRGBA{255, 255, 255, 255}, model.FitBorderThickness*ppRatio*.5)
+
+	// the embedded fonts are encoded as cp1252, which has the superscript two but not the chi
+	toFont := pdf.UnicodeTranslatorFromDescriptor("")
+	size := mAdj.FitFontSize()
+	for _, t := range mAdj.FitTexts() {
+		textPos := toPage(t.Pos)
+		textPos.X -= textAdj
+		txt, ok := strings.CutPrefix(t.Text, "χ")
+		if ok {
+			textPos.X += DrawSymbol(pdf, textPos, "c", size, ppRatio)
 		}
+		DrawText(pdf, textPos, toFont(txt), m.Font.Family, false, size, ppRatio)
+	}
+}
+
+// drawR2 draws the variance explained of an endogenous node under its name or as a badge
+func drawR2(pdf *gofpdf.Fpdf, m, mAdj *model.Model, n *model.Node, offsetX, offsetY float32) {
+	toPage := func(pos utils.LocalPos) utils.LocalPos {
+		return utils.LocalPos{X: (pos.X + offsetX) * ppRatio, Y: (pos.Y + offsetY) * ppRatio}
 	}
 
-	dim = utils.LocalDim{
-		W: utils.Abs32(rect[1].X - rect[0].X),
-		H: utils.Abs32(rect[1].Y - rect[0].Y),
+	if mAdj.R2Display == model.R2_BADGE {
+		rect := mAdj.R2BadgeRect(n)
+		dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+		DrawRect(pdf, toPage(rect[0]), dim.Mul(ppRatio), color.NRGBA{255, 255, 255, 255}, model.R2BadgeThickness*ppRatio*.5)
 	}
 
-	return
+	txt, center := mAdj.R2Label(n)
+	size := mAdj.R2FontSize()
+	textWidth := utils.GetTextWidth(txt, m.Font.Face, size*ppRatio, layout.Context{})
+	textPos := toPage(center)
+	textPos.X -= textWidth/2 + textAdj
+	// the superscript two is translated to the cp1252 encoding of the embedded fonts
+	DrawText(pdf, textPos, pdf.UnicodeTranslatorFromDescriptor("")(txt), m.Font.Family, false, size, ppRatio)
 }
 
 func createTempFontDir() string {
@@ -225,11 +312,3 @@ func createTempFontDir() string {
 
 	return tempDir
 }
-
-func transformModel(m *model.Model) *model.Model {
-	res := m.Clone()
-	for _, n := range res.Nodes {
-		n.Dim = n.Dim.Div(m.PxPerDp)
-	}
-	return res
-}
@@ -0,0 +1,327 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"main/utils"
+	"math"
+
+	"gioui.org/f32"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+const curveSamples = 48
+
+func DrawRect(img *image.RGBA, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Draw fill
+	fillPath(img, [][]f32.Point{rectPoints(pos, dim, 0)}, col)
+
+	// Draw outline if thickness > 0
+	if thickness > 0 {
+		outer := rectPoints(pos, dim, thickness/2)
+		inner := reversed(rectPoints(pos, dim, -thickness/2))
+		fillPath(img, [][]f32.Point{outer, inner}, color.NRGBA{A: 255})
+	}
+}
+
+func DrawEllipse(img *image.RGBA, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Draw fill
+	fillPath(img, [][]f32.Point{ellipsePoints(pos, dim, 0)}, col)
+
+	// Draw outline
+	outer := ellipsePoints(pos, dim, thickness/2)
+	inner := reversed(ellipsePoints(pos, dim, -thickness/2))
+	fillPath(img, [][]f32.Point{outer, inner}, color.NRGBA{A: 255})
+}
+
+func DrawTriangle(img *image.RGBA, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Draw fill
+	fillPath(img, [][]f32.Point{trianglePoints(pos, dim, 0)}, col)
+
+	// Draw outline
+	outer := trianglePoints(pos, dim, thickness/2)
+	inner := reversed(trianglePoints(pos, dim, -thickness/2))
+	fillPath(img, [][]f32.Point{outer, inner}, color.NRGBA{A: 255})
+}
+
+func DrawHexagon(img *image.RGBA, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Draw fill
+	fillPath(img, [][]f32.Point{hexagonPoints(pos, dim, 0)}, col)
+
+	// Draw outline
+	outer := hexagonPoints(pos, dim, thickness/2)
+	inner := reversed(hexagonPoints(pos, dim, -thickness/2))
+	fillPath(img, [][]f32.Point{outer, inner}, color.NRGBA{A: 255})
+}
+
+func DrawArrowLine(img *image.RGBA, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
+	angle := utils.GetAngleLoc(posA, posB)
+	arrowSize := thickness * 5
+
+	// Draw line shortened at posB to accommodate arrow
+	endPos := utils.MoveAlongAngleLoc(posB, angle+math.Pi, arrowSize*0.5)
+	DrawLine(img, posA, endPos, col, thickness)
+
+	// Draw arrow head at posB
+	DrawArrowHead(img, posB, angle, arrowSize, col)
+}
+
+func DrawArrowCurve(img *image.RGBA, posA, posB utils.LocalPos, col color.NRGBA, thickness float32, curvature float32) {
+	// Calculate control point for quadratic bezier
+	ctrl := utils.GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
+
+	arrowSize := thickness * 5
+
+	// Angle at start: from posA toward control point
+	angleA := -math.Atan2(float64(ctrl.Y-posA.Y), float64(ctrl.X-posA.X)) + math.Pi
+
+	// Angle at end: from control point toward posB
+	angleB := -math.Atan2(float64(posB.Y-ctrl.Y), float64(posB.X-ctrl.X))
+
+	// Draw the curve shortened at both ends
+	startPos := utils.MoveAlongAngleLoc(posA, angleA+math.Pi, arrowSize*0.5)
+	endPos := utils.MoveAlongAngleLoc(posB, angleB+math.Pi, arrowSize*0.5)
+	DrawCurve(img, startPos, endPos, col, thickness, curvature)
+
+	// Draw arrow heads
+	DrawArrowHead(img, posA, angleA, arrowSize, col)
+	DrawArrowHead(img, posB, angleB, arrowSize, col)
+}
+
+func DrawLine(img *image.RGBA, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
+	fillPath(img, [][]f32.Point{strokeOutline([]f32.Point{posA.ToF32(), posB.ToF32()}, thickness)}, col)
+}
+
+func DrawCurve(img *image.RGBA, posA, posB utils.LocalPos, col color.NRGBA, thickness float32, curvature float32) {
+	ctrl := utils.GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
+
+	// flatten the quadratic bezier into a polyline before stroking
+	pts := make([]f32.Point, curveSamples+1)
+	for i := range pts {
+		t := float32(i) / curveSamples
+		pts[i] = utils.MoveAlongBezier(posA.ToF32(), posB.ToF32(), ctrl, t).ToF32()
+	}
+
+	fillPath(img, [][]f32.Point{strokeOutline(pts, thickness)}, col)
+}
+
+func DrawArrowHead(img *image.RGBA, basePos utils.LocalPos, angle float64, size float32, col color.NRGBA) {
+	// Calculate triangle points
+	p1 := utils.MoveAlongAngleLoc(basePos, angle+math.Pi+math.Pi/7.0, size)
+	p2 := utils.MoveAlongAngleLoc(basePos, angle+math.Pi-math.Pi/7.0, size)
+
+	fillPath(img, [][]f32.Point{{p1.ToF32(), p2.ToF32(), basePos.ToF32()}}, col)
+}
+
+func DrawArc(img *image.RGBA, posA, posB, refPoint utils.LocalPos, radius float32, offsetAngle float64, col color.NRGBA, thickness float32) {
+	circleCenter := utils.FindCircleCenter(posA.ToF32(), posB.ToF32(), refPoint.ToF32(), radius)
+	angleA := utils.GetAngle(circleCenter, posA.ToF32())
+	angleB := utils.GetAngle(circleCenter, posB.ToF32())
+
+	offsetAngle *= 0.9 // shorten the offset angle a bit
+
+	startAngle := utils.NormalizeAngle(angleB - offsetAngle)
+
+	angleDiff := math.Mod(angleB-angleA+math.Pi, 2*math.Pi) - math.Pi
+	angle := utils.NormalizeAngle(angleDiff - 2*offsetAngle)
+
+	// the arc sweeps clockwise from the truncated end at posB
+	pts := make([]f32.Point, curveSamples+1)
+	for i := range pts {
+		a := startAngle - angle*float64(i)/curveSamples
+		pts[i] = utils.MoveAlongAngle(circleCenter, a, radius)
+	}
+
+	fillPath(img, [][]f32.Point{strokeOutline(pts, thickness)}, col)
+}
+
+func DrawArrowArc(img *image.RGBA, posA, posB, refPoint utils.LocalPos, radius float32, col color.NRGBA, thickness float32) {
+	circleCenter := utils.FindCircleCenter(posA.ToF32(), posB.ToF32(), refPoint.ToF32(), radius)
+	arrowSize := float64(thickness * 5)
+	offsetAngle := arrowSize / float64(radius)
+	angleA := utils.GetAngle(circleCenter, posA.ToF32())
+	angleB := utils.GetAngle(circleCenter, posB.ToF32())
+	angleTangentA := utils.NormalizeAngle(angleA + offsetAngle - math.Pi/2)
+	angleTangentB := utils.NormalizeAngle(angleB - offsetAngle + math.Pi/2)
+
+	DrawArc(img, posA, posB, refPoint, radius, arrowSize/float64(radius), col, thickness)
+
+	truncatedPosA := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(angleA+offsetAngle), radius)
+	truncatedPosB := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(angleB-offsetAngle), radius)
+
+	arrowPosA := utils.MoveAlongAngle(truncatedPosA, angleTangentA, float32(arrowSize)-thickness)
+	arrowPosB := utils.MoveAlongAngle(truncatedPosB, angleTangentB, float32(arrowSize)-thickness)
+
+	DrawArrowHead(img, utils.ToLocalPos(arrowPosA), angleTangentA, float32(arrowSize), col)
+	DrawArrowHead(img, utils.ToLocalPos(arrowPosB), angleTangentB, float32(arrowSize), col)
+}
+
+// DrawText draws text centered on pos
+func DrawText(img *image.RGBA, pos utils.LocalPos, txt string, face font.Face) {
+	width := font.MeasureString(face, txt)
+	DrawTextLeft(img, pos.Sub(utils.LocalPos{X: float32(width) / 64 / 2}), txt, face)
+}
+
+// DrawTextLeft draws text starting at pos, centered vertically
+func DrawTextLeft(img *image.RGBA, pos utils.LocalPos, txt string, face font.Face) {
+	metrics := face.Metrics()
+
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.NRGBA{A: 255}),
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: fixed.Int26_6(pos.X * 64),
+			Y: fixed.Int26_6(pos.Y*64) + (metrics.Ascent-metrics.Descent)/2,
+		},
+	}
+	d.DrawString(txt)
+}
+
+// fillPath fills the (nonzero winding) polygons with the given color.
+// Only the bounding box of the polygons is rasterized to keep memory use low at high resolutions.
+func fillPath(img *image.RGBA, polygons [][]f32.Point, col color.NRGBA) {
+	minPt := f32.Pt(math.MaxFloat32, math.MaxFloat32)
+	maxPt := f32.Pt(-math.MaxFloat32, -math.MaxFloat32)
+	for _, poly := range polygons {
+		for _, p := range poly {
+			minPt = f32.Pt(min(minPt.X, p.X), min(minPt.Y, p.Y))
+			maxPt = f32.Pt(max(maxPt.X, p.X), max(maxPt.Y, p.Y))
+		}
+	}
+
+	bounds := image.Rect(
+		int(math.Floor(float64(minPt.X))), int(math.Floor(float64(minPt.Y))),
+		int(math.Ceil(float64(maxPt.X))), int(math.Ceil(float64(maxPt.Y))),
+	).Intersect(img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	origin := f32.Pt(float32(bounds.Min.X), float32(bounds.Min.Y))
+	z := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	for _, poly := range polygons {
+		if len(poly) < 3 {
+			continue
+		}
+		z.MoveTo(poly[0].X-origin.X, poly[0].Y-origin.Y)
+		for _, p := range poly[1:] {
+			z.LineTo(p.X-origin.X, p.Y-origin.Y)
+		}
+		z.ClosePath()
+	}
+	z.Draw(img, bounds, image.NewUniform(col), image.Point{})
+}
+
+// strokeOutline converts a polyline into a closed polygon of the given width
+func strokeOutline(pts []f32.Point, width float32) []f32.Point {
+	if len(pts) < 2 {
+		return nil
+	}
+
+	left := make([]f32.Point, len(pts))
+	right := make([]f32.Point, len(pts))
+	for i := range pts {
+		// average the normals of the adjacent segments to join them smoothly
+		var dir f32.Point
+		if i > 0 {
+			dir = dir.Add(unit(pts[i].Sub(pts[i-1])))
+		}
+		if i < len(pts)-1 {
+			dir = dir.Add(unit(pts[i+1].Sub(pts[i])))
+		}
+		normal := unit(f32.Pt(-dir.Y, dir.X)).Mul(width / 2)
+		left[i] = pts[i].Add(normal)
+		right[i] = pts[i].Sub(normal)
+	}
+
+	return append(left, reversed(right)...)
+}
+
+// rectPoints returns the corners of a rectangle, grown outward by inset on every side
+func rectPoints(pos utils.LocalPos, dim utils.LocalDim, inset float32) []f32.Point {
+	return []f32.Point{
+		{X: pos.X - inset, Y: pos.Y - inset},
+		{X: pos.X + dim.W + inset, Y: pos.Y - inset},
+		{X: pos.X + dim.W + inset, Y: pos.Y + dim.H + inset},
+		{X: pos.X - inset, Y: pos.Y + dim.H + inset},
+	}
+}
+
+// trianglePoints returns the vertices of an upward-pointing triangle, with every edge moved outward by inset
+func trianglePoints(pos utils.LocalPos, dim utils.LocalDim, inset float32) []f32.Point {
+	vertices := utils.TriangleVertices(pos.AddDim(dim.Div(2)), dim)
+
+	// the incenter is equidistant from every edge, so scaling about it offsets each edge equally
+	a, b, c := vertices[0].ToF32(), vertices[1].ToF32(), vertices[2].ToF32()
+	la, lb, lc := dist(b, c), dist(c, a), dist(a, b)
+	perimeter := la + lb + lc
+	incenter := a.Mul(la).Add(b.Mul(lb)).Add(c.Mul(lc)).Div(perimeter)
+	area := utils.Abs32((b.X-a.X)*(c.Y-a.Y)-(c.X-a.X)*(b.Y-a.Y)) / 2
+	inradius := 2 * area / perimeter
+
+	factor := (inradius + inset) / inradius
+	pts := make([]f32.Point, 3)
+	for i, v := range []f32.Point{a, b, c} {
+		pts[i] = incenter.Add(v.Sub(incenter).Mul(factor))
+	}
+	return pts
+}
+
+// hexagonPoints returns the corners of the hexagon with every edge moved outward by inset
+func hexagonPoints(pos utils.LocalPos, dim utils.LocalDim, inset float32) []f32.Point {
+	vertices := utils.HexagonVertices(pos.AddDim(dim.Div(2)), dim)
+
+	// outward normal of the edge from vertex i to the next one. The vertices go clockwise on the screen
+	normal := func(i int) f32.Point {
+		a, b := vertices[i].ToF32(), vertices[(i+1)%len(vertices)].ToF32()
+		d := b.Sub(a)
+		return f32.Pt(d.Y, -d.X).Div(dist(a, b))
+	}
+
+	pts := make([]f32.Point, len(vertices))
+	for i, v := range vertices {
+		// the miter of the two edges meeting at the corner
+		n1, n2 := normal((i+len(vertices)-1)%len(vertices)), normal(i)
+		miter := n1.Add(n2).Div(1 + n1.X*n2.X + n1.Y*n2.Y)
+		pts[i] = v.ToF32().Add(miter.Mul(inset))
+	}
+	return pts
+}
+
+// ellipsePoints returns points along an ellipse inscribed in the rectangle, grown outward by inset
+func ellipsePoints(pos utils.LocalPos, dim utils.LocalDim, inset float32) []f32.Point {
+	center := f32.Pt(pos.X+dim.W/2, pos.Y+dim.H/2)
+	rx := dim.W/2 + inset
+	ry := dim.H/2 + inset
+
+	n := curveSamples * 2
+	pts := make([]f32.Point, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		pts[i] = f32.Pt(center.X+rx*float32(math.Cos(a)), center.Y+ry*float32(math.Sin(a)))
+	}
+	return pts
+}
+
+func reversed(pts []f32.Point) []f32.Point {
+	res := make([]f32.Point, len(pts))
+	for i, p := range pts {
+		res[len(pts)-1-i] = p
+	}
+	return res
+}
+
+func dist(a, b f32.Point) float32 {
+	return float32(math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y)))
+}
+
+func unit(p f32.Point) f32.Point {
+	l := float32(math.Hypot(float64(p.X), float64(p.Y)))
+	if l == 0 {
+		return f32.Point{}
+	}
+	return p.Div(l)
+}
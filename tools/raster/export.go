@@ -0,0 +1,316 @@
+package raster
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"main/model"
+	"main/utils"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/tiff"
+)
+
+const (
+	docPadding = 20
+	baseDPI    = 96 // model units are treated as CSS pixels, which are defined at 96 dpi
+)
+
+// ExportModel renders the model to a PNG or TIFF file (chosen by the file extension) at the given resolution
+func ExportModel(m *model.Model, filePath string, dpi float64) {
+	// check for different PxPerDp. If not one, copy the model and apply a transformation
+	mAdj := model.PrepareForExport(m)
+
+	scale := float32(dpi / baseDPI)
+	fonts := utils.LoadRasterFonts(m.Font.Family)
+	faces := []font.Face{
+		newFace(fonts[0], m.Font.Size*scale),
+		newFace(fonts[1], m.Font.Size*scale),
+		newFace(fonts[0], (m.Font.Size-2)*scale),
+	}
+
+	switch {
+	case !mAdj.IsMultiGroup():
+		rect, localDim := model.GetModelSize(mAdj)
+		img := newImage(utils.LocalDim{W: localDim.W + 2*docPadding, H: localDim.H + 2*docPadding}, scale)
+
+		// Offset to translate model coordinates to image coordinates
+		offset := utils.LocalPos{X: docPadding - rect[0].X, Y: docPadding - rect[0].Y}
+		drawModel(img, m, mAdj, offset, scale, faces)
+
+		writeImage(img, filePath, dpi)
+	case mAdj.GroupPanels:
+		// place every group side by side in a single image
+		activeGroup := mAdj.ActiveGroup
+		titleHeight := m.Font.Size * 2
+		panels, docDim := model.LayoutPanels(mAdj, docPadding, titleHeight)
+
+		img := newImage(docDim, scale)
+		for _, p := range panels {
+			model.ActivateGroupForExport(mAdj, p.Group)
+			titlePos := utils.LocalPos{X: p.Pos.X + p.Dim.W/2, Y: p.Pos.Y + titleHeight/2}
+			DrawText(img, titlePos.Mul(scale), p.Title, faces[1])
+			drawModel(img, m, mAdj, p.Offset, scale, faces)
+		}
+		model.ActivateGroupForExport(mAdj, activeGroup)
+
+		writeImage(img, filePath, dpi)
+	default:
+		// one file per group
+		activeGroup := mAdj.ActiveGroup
+		for _, g := range mAdj.Groups {
+			model.ActivateGroupForExport(mAdj, g)
+
+			rect, localDim := model.GetModelSize(mAdj)
+			img := newImage(utils.LocalDim{W: localDim.W + 2*docPadding, H: localDim.H + 2*docPadding}, scale)
+			offset := utils.LocalPos{X: docPadding - rect[0].X, Y: docPadding - rect[0].Y}
+			drawModel(img, m, mAdj, offset, scale, faces)
+
+			writeImage(img, model.GroupFilePath(filePath, g), dpi)
+		}
+		model.ActivateGroupForExport(mAdj, activeGroup)
+	}
+}
+
+// newImage returns a white image large enough to hold the document at the given scale
+func newImage(docDim utils.LocalDim, scale float32) *image.RGBA {
+	imgWidth := int(math.Ceil(float64(docDim.W * scale)))
+	imgHeight := int(math.Ceil(float64(docDim.H * scale)))
+
+	img := image.NewRGBA(image.Rect(0, 0, imgWidth, imgHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	return img
+}
+
+// drawModel draws the diagram translated by offset. faces holds the normal, bold and estimate font faces
+func drawModel(img *image.RGBA, m, mAdj *model.Model, offset utils.LocalPos, scale float32, faces []font.Face) {
+	toImage := func(pos utils.LocalPos) utils.LocalPos {
+		return pos.Add(offset).Mul(scale)
+	}
+
+	for _, n := range mAdj.Nodes {
+		if !n.Visible {
+			continue
+		}
+
+		// Convert center position to top-left corner
+		adjPos := toImage(n.Pos.SubDim(n.Dim.Div(2)))
+		adjDim := n.Dim.Mul(scale)
+
+		switch n.Class {
+		case model.OBSERVED:
+			DrawRect(img, adjPos, adjDim, n.Col, n.Thickness*scale*.5) // .5 matches the outline weight of the PDF export
+		case model.LATENT:
+			DrawEllipse(img, adjPos, adjDim, n.Col, n.Thickness*scale*.5)
+		case model.INTERCEPT:
+			DrawTriangle(img, adjPos, adjDim, n.Col, n.Thickness*scale*.5)
+		case model.COMPOSITE:
+			DrawHexagon(img, adjPos, adjDim, n.Col, n.Thickness*scale*.5)
+		}
+
+		textPos := n.Pos
+		if n.Class == model.INTERCEPT {
+			// center the text on the centroid of the triangle
+			textPos.Y += n.Dim.H / 6
+		}
+		textPos.Y += mAdj.NodeTextShift(n)
+
+		face := faces[0]
+		if n.Bold {
+			face = faces[1]
+		}
+		DrawText(img, toImage(textPos), n.Text, face)
+
+		if mAdj.ShowsThresholds(n) {
+			switch mAdj.ThresholdDisplay {
+			case model.THRESHOLDS_TICKS:
+				for _, l := range model.ThresholdTicks(n) {
+					DrawLine(img, toImage(l[0]), toImage(l[1]), color.NRGBA{A: 255}, model.ThresholdThickness*scale)
+				}
+			case model.THRESHOLDS_LIST:
+				// the list uses the size of the estimate labels
+				txt, center := mAdj.ThresholdLabel(n)
+				DrawText(img, toImage(center), txt, faces[2])
+			}
+		}
+	}
+
+	for _, c := range mAdj.Connections {
+		if !c.UserDefined && !mAdj.ViewGenerated {
+			continue
+		}
+
+		originPos := toImage(c.OriginPos)
+		destPos := toImage(c.DestinationPos)
+
+		switch c.Type {
+		case model.STRAIGHT:
+			DrawArrowLine(img, originPos, destPos, mAdj.ConnectionColor(c), c.Thickness*scale)
+		case model.CURVED:
+			DrawArrowCurve(img, originPos, destPos, mAdj.ConnectionColor(c), c.Thickness*scale, c.Curvature)
+		case model.CIRCULAR:
+			DrawArrowArc(img, originPos, destPos, toImage(c.RefPos), model.VarianceRadius*scale, mAdj.ConnectionColor(c), c.Thickness*scale)
+		}
+	}
+
+	// draw estimate labels after all the connections to ensure proper layering
+	for _, c := range mAdj.Connections {
+		if !mAdj.ShowsEstimate(c) {
+			continue
+		}
+
+		rectDim := c.EstDim.Div(m.PxPerDp)
+		rectPos := toImage(c.EstPos.SubDim(rectDim.Div(2)))
+
+		DrawRect(img, rectPos, rectDim.Mul(scale), color.NRGBA{255, 255, 255, 255}, 0)
+		DrawText(img, toImage(c.EstPos), c.EstText, faces[2])
+	}
+
+	// the variance explained uses the size of the estimate labels
+	for _, n := range mAdj.Nodes {
+		if !mAdj.ShowsR2(n) {
+			continue
+		}
+
+		if mAdj.R2Display == model.R2_BADGE {
+			rect := mAdj.R2BadgeRect(n)
+			DrawRect(img, toImage(rect[0]), utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}.Mul(scale), color.NRGBA{255, 255, 255, 255}, model.R2BadgeThickness*scale*.5)
+		}
+		txt, center := mAdj.R2Label(n)
+		DrawText(img, toImage(center), txt, faces[2])
+	}
+
+	if mAdj.ShowsEffects() {
+		for _, l := range mAdj.EffectsRules() {
+			DrawLine(img, toImage(l[0]), toImage(l[1]), color.NRGBA{A: 255}, model.EffectsRuleThickness*scale)
+		}
+		// the block uses the size of the estimate labels
+		for _, t := range mAdj.EffectsTexts() {
+			DrawTextLeft(img, toImage(t.Pos), t.Text, faces[2])
+		}
+	}
+
+	if mAdj.ShowsFit() {
+		rect := mAdj.FitRect()
+		dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+		DrawRect(img, toImage(rect[0]), dim.Mul(scale), color.NRGBA{255, 255, 255, 255}, model.FitBorderThickness*scale*.5)
+		for _, t := range mAdj.FitTexts() {
+			DrawTextLeft(img, toImage(t.Pos), t.Text, faces[2])
+		}
+	}
+}
+
+// writeImage encodes the image in the format given by the file extension, recording its resolution
+func writeImage(img *image.RGBA, filePath string, dpi float64) {
+	var buf bytes.Buffer
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".png":
+		err = png.Encode(&buf, img)
+		if err == nil {
+			err = setPngDPI(&buf, dpi)
+		}
+	case ".tif", ".tiff":
+		err = tiff.Encode(&buf, img, &tiff.Options{Compression: tiff.Deflate})
+		if err == nil {
+			err = setTiffDPI(buf.Bytes(), dpi)
+		}
+	default:
+		err = fmt.Errorf("unsupported raster format %q", ext)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.WriteFile(filePath, buf.Bytes(), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newFace(f *opentype.Font, size float32) font.Face {
+	// a DPI of 72 makes the face size equal to the pixel size
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		panic(err)
+	}
+	return face
+}
+
+// setPngDPI inserts a pHYs chunk directly after the IHDR chunk, since image/png does not write one
+func setPngDPI(buf *bytes.Buffer, dpi float64) error {
+	data := buf.Bytes()
+	// 8 byte signature + IHDR chunk (4 length + 4 type + 13 data + 4 crc)
+	ihdrEnd := 8 + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd || string(data[12:16]) != "IHDR" {
+		return fmt.Errorf("unexpected png layout")
+	}
+
+	pxPerMeter := uint32(math.Round(dpi / 0.0254))
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk[0:4], 9)
+	copy(chunk[4:8], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:12], pxPerMeter)
+	binary.BigEndian.PutUint32(chunk[12:16], pxPerMeter)
+	chunk[16] = 1 // unit is the meter
+	binary.BigEndian.PutUint32(chunk[17:21], crc32.ChecksumIEEE(chunk[4:17]))
+
+	res := make([]byte, 0, len(data)+len(chunk))
+	res = append(res, data[:ihdrEnd]...)
+	res = append(res, chunk...)
+	res = append(res, data[ihdrEnd:]...)
+
+	buf.Reset()
+	buf.Write(res)
+	return nil
+}
+
+// setTiffDPI overwrites the placeholder 72 dpi resolution written by x/image/tiff
+func setTiffDPI(data []byte, dpi float64) error {
+	if len(data) < 8 || string(data[0:2]) != "II" {
+		return fmt.Errorf("unexpected tiff layout")
+	}
+	enc := binary.LittleEndian
+
+	const (
+		tagXResolution = 282
+		tagYResolution = 283
+	)
+
+	ifdOffset := int(enc.Uint32(data[4:8]))
+	if ifdOffset+2 > len(data) {
+		return fmt.Errorf("unexpected tiff layout")
+	}
+	numEntries := int(enc.Uint16(data[ifdOffset : ifdOffset+2]))
+	for i := 0; i < numEntries; i++ {
+		entry := ifdOffset + 2 + i*12
+		if entry+12 > len(data) {
+			return fmt.Errorf("unexpected tiff layout")
+		}
+
+		tag := enc.Uint16(data[entry : entry+2])
+		if tag != tagXResolution && tag != tagYResolution {
+			continue
+		}
+
+		// rationals are stored out of line as a numerator and a denominator
+		valueOffset := int(enc.Uint32(data[entry+8 : entry+12]))
+		if valueOffset+8 > len(data) {
+			return fmt.Errorf("unexpected tiff layout")
+		}
+		enc.PutUint32(data[valueOffset:valueOffset+4], uint32(math.Round(dpi)))
+		enc.PutUint32(data[valueOffset+4:valueOffset+8], 1)
+	}
+
+	return nil
+}
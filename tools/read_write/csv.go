@@ -0,0 +1,281 @@
+package read_write
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// column names used by lavaan, semopy and common spreadsheet exports for each field of a DataRow
+var csvColumnAliases = map[string][]string{
+	"lhs":          {"lhs", "lval"},
+	"op":           {"op"},
+	"rhs":          {"rhs", "rval"},
+	"user":         {"user"},
+	"group":        {"group"},
+	"est":          {"est", "estimate", "est.std", "std.all"},
+	"se":           {"se", "std. err", "std.err", "std_err"},
+	"label":        {"label"},
+	"pvalue":       {"pvalue", "p-value", "p.value", "p_value", "p"},
+	"ci_lower":     {"ci.lower", "ci_lower", "lower", "pi.lower", "hpd.lower"},
+	"ci_upper":     {"ci.upper", "ci_upper", "upper", "pi.upper", "hpd.upper"},
+	"median":       {"median", "post.median", "post_median"},
+	"rhat":         {"rhat", "psrf", "r_hat"},
+	"std_all":      {"std_all", "std.all"},
+	"std_se":       {"std_se", "se.std"},
+	"std_pvalue":   {"std_pvalue", "pvalue.std"},
+	"std_ci_lower": {"std_ci_lower", "ci.lower.std"},
+	"std_ci_upper": {"std_ci_upper", "ci.upper.std"},
+}
+
+// ParseColumnMapping reads a mapping such as "est=Estimate,pvalue=P(>|z|)" from fields to the columns of a CSV file
+func ParseColumnMapping(s string) (map[string]string, error) {
+	res := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return res, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected field=column", pair)
+		}
+		if _, known := csvColumnAliases[field]; !known {
+			return nil, fmt.Errorf("unknown field %q in column mapping", field)
+		}
+		res[field] = strings.TrimSpace(column)
+	}
+	return res, nil
+}
+
+// ReadCSV reads a parameter table from a CSV file. Columns are found by their usual names unless columns maps a field
+// to another column
+func ReadCSV(path string, columns map[string]string) ([]DataRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ParseCSV(string(data), columns)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// ParseCSV translates a CSV parameter table into rows. The separator may be a comma, a semicolon (with decimal commas,
+// as written by write.csv2) or a tab. Missing values such as NA or "-" are read as zero, while NaN is kept for
+// validation to report. Groups given by name are numbered in the order they appear.
+func ParseCSV(data string, columns map[string]string) ([]DataRow, error) {
+	header, _, _ := strings.Cut(data, "\n")
+	sep := ','
+	for _, c := range []rune{';', '\t'} {
+		if strings.Count(header, string(c)) > strings.Count(header, string(sep)) {
+			sep = c
+		}
+	}
+
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comma = sep
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no parameters found")
+	}
+
+	index, err := csvColumnIndex(records[0], columns)
+	if err != nil {
+		return nil, err
+	}
+
+	cell := func(record []string, field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	number := func(record []string, field string, line int) (float64, error) {
+		s := cell(record, field)
+		switch strings.ToUpper(s) {
+		case "", "NA", "NULL", "-", ".":
+			return 0, nil
+		}
+		if sep == ';' {
+			s = strings.ReplaceAll(s, ",", ".")
+		}
+		// p-values are sometimes reported as "<.001"
+		s = strings.TrimLeft(s, "<>= ")
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: column %s: %q is not a number", line, field, cell(record, field))
+		}
+		return v, nil
+	}
+
+	groupNumbers := make(map[string]int)
+	var rows []DataRow
+	for i, record := range records[1:] {
+		line := i + 2
+
+		row := DataRow{
+			Lhs:   cell(record, "lhs"),
+			Op:    cell(record, "op"),
+			Rhs:   cell(record, "rhs"),
+			Label: cell(record, "label"),
+			User:  1,
+			Group: 1,
+		}
+		if row.Lhs == "" && row.Op == "" {
+			continue
+		}
+
+		// semopy writes intercepts as regressions on the constant 1
+		if row.Op == "~" && row.Rhs == "1" {
+			row.Op, row.Rhs = "~1", ""
+		}
+
+		if _, ok := index["user"]; ok {
+			switch strings.ToUpper(cell(record, "user")) {
+			case "0", "FALSE", "F":
+				row.User = 0
+			}
+		}
+
+		if g := cell(record, "group"); g != "" {
+			n, err := strconv.Atoi(g)
+			if err != nil {
+				// groups given by name
+				if _, ok := groupNumbers[g]; !ok {
+					groupNumbers[g] = len(groupNumbers) + 1
+				}
+				n = groupNumbers[g]
+			}
+			row.Group = n
+		}
+
+		if row.Est, err = number(record, "est", line); err != nil {
+			return nil, err
+		}
+		if row.PValue, err = number(record, "pvalue", line); err != nil {
+			return nil, err
+		}
+		if row.CiLower, err = number(record, "ci_lower", line); err != nil {
+			return nil, err
+		}
+		if row.CiUpper, err = number(record, "ci_upper", line); err != nil {
+			return nil, err
+		}
+		if row.Median, err = number(record, "median", line); err != nil {
+			return nil, err
+		}
+		if row.Rhat, err = number(record, "rhat", line); err != nil {
+			return nil, err
+		}
+		if row.SE, err = number(record, "se", line); err != nil {
+			return nil, err
+		}
+		if row.StdAll, err = number(record, "std_all", line); err != nil {
+			return nil, err
+		}
+		if row.StdSE, err = number(record, "std_se", line); err != nil {
+			return nil, err
+		}
+		if row.StdPValue, err = number(record, "std_pvalue", line); err != nil {
+			return nil, err
+		}
+		if row.StdCiLower, err = number(record, "std_ci_lower", line); err != nil {
+			return nil, err
+		}
+		if row.StdCiUpper, err = number(record, "std_ci_upper", line); err != nil {
+			return nil, err
+		}
+
+		// without intervals in the table they are calculated from the standard error
+		_, hasLower := index["ci_lower"]
+		_, hasUpper := index["ci_upper"]
+		_, hasSE := index["se"]
+		if (!hasLower || !hasUpper) && hasSE {
+			row.CiLower, row.CiUpper = row.Est-1.96*row.SE, row.Est+1.96*row.SE
+		}
+		_, hasStdLower := index["std_ci_lower"]
+		_, hasStdUpper := index["std_ci_upper"]
+		_, hasStdSE := index["std_se"]
+		if (!hasStdLower || !hasStdUpper) && hasStdSE {
+			row.StdCiLower, row.StdCiUpper = row.StdAll-1.96*row.StdSE, row.StdAll+1.96*row.StdSE
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// regressionLoadings turns the regressions of indicators on the latent variables, which is how semopy writes loadings,
+// into lavaan's "=~". Like any path out of a latent variable, a regression of one latent variable on another becomes a
+// loading, which draws the same arrow and keeps higher-order factors latent
+func regressionLoadings(rows []DataRow, latent []string) error {
+	for _, name := range latent {
+		if !slices.ContainsFunc(rows, func(row DataRow) bool { return row.Lhs == name || row.Rhs == name }) {
+			return fmt.Errorf("latent variable %q is not in the parameter table", name)
+		}
+	}
+
+	for i, row := range rows {
+		if row.Op == "~" && slices.Contains(latent, row.Rhs) {
+			rows[i].Lhs, rows[i].Op, rows[i].Rhs = row.Rhs, "=~", row.Lhs
+		}
+	}
+	return nil
+}
+
+// csvColumnIndex finds the column of each field, preferring the mapping given by the user over the usual names
+func csvColumnIndex(header []string, columns map[string]string) (map[string]int, error) {
+	find := func(name string) (int, bool) {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	index := make(map[string]int)
+	for field, aliases := range csvColumnAliases {
+		if column, ok := columns[field]; ok {
+			i, found := find(column)
+			if !found {
+				return nil, fmt.Errorf("column %q mapped to %s not found", column, field)
+			}
+			index[field] = i
+			continue
+		}
+
+		for _, alias := range aliases {
+			if i, found := find(alias); found {
+				index[field] = i
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range []string{"lhs", "op", "rhs"} {
+		if _, ok := index[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no column for %s, map one with field=column", strings.Join(missing, ", "))
+	}
+
+	return index, nil
+}
@@ -0,0 +1,79 @@
+package read_write
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/model"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InputOptions holds the settings of the input formats that need them
+type InputOptions struct {
+	Standardized bool              // use the standardized results of formats that hold both
+	Columns      map[string]string // columns of a CSV parameter table that do not have their usual names
+	Latent       []string          // latent variables of a CSV parameter table that writes loadings as regressions
+}
+
+// ReadInput reads the parameter table of a model from a file. The format is given by the file extension:
+// .json for a parameter table written by the R package or an OpenMx RAM model, .csv or .tsv for a parameter table,
+// .lav or .lavaan for lavaan model syntax and .out for Mplus output
+func ReadInput(path string, opts InputOptions) ([]DataRow, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if isRAMJSON(data) {
+			return ReadRAM(path)
+		}
+		return ReadRows(path)
+	case ".csv", ".tsv":
+		rows, err := ReadCSV(path, opts.Columns)
+		if err != nil {
+			return nil, err
+		}
+		if err := regressionLoadings(rows, opts.Latent); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return rows, nil
+	case ".lav", ".lavaan":
+		return ReadLavaan(path)
+	case ".out":
+		return ReadMplus(path, opts.Standardized)
+	default:
+		return nil, fmt.Errorf("%s: unknown input format %q", path, ext)
+	}
+}
+
+// ReadFitMeasures reads the fit indices that come with the model: the "fit_measures" object of a JSON input or the
+// MODEL FIT INFORMATION of Mplus output. Other formats have none
+func ReadFitMeasures(path string) (model.FitMeasures, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !isJSONObject(data) {
+			return nil, nil
+		}
+		var table struct {
+			FitMeasures model.FitMeasures `json:"fit_measures"`
+		}
+		if err := json.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("%s: fit_measures: %w", path, err)
+		}
+		return table.FitMeasures, nil
+	case ".out":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseMplusFit(string(data)), nil
+	default:
+		return nil, nil
+	}
+}
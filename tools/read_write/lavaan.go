@@ -0,0 +1,368 @@
+package read_write
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// lavaan operators, longest first so that e.g. "=~" is not read as "="
+var lavaanOps = []string{"~*~", "=~", "~~", "<~", ":=", "==", "|", "<", ">", "~"}
+
+// ReadLavaan reads a file of lavaan model syntax
+func ReadLavaan(path string) ([]DataRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ParseLavaan(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// ParseLavaan translates lavaan model syntax into the rows of a parameter table as they would come from lavaan,
+// without any estimates. Fixed values such as the 1 in "f =~ 1*x1" are stored as the estimate of their row.
+// Like lavaan, the first loading of each latent variable is fixed to one unless it is modified, and variances
+// are added for every variable that is not given one explicitly.
+func ParseLavaan(src string) ([]DataRow, error) {
+	var params []lavaanParam
+
+	for _, stmt := range lavaanStatements(src) {
+		stmtRows, err := parseLavaanStatement(stmt.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", stmt.line, err)
+		}
+		params = append(params, stmtRows...)
+	}
+
+	if len(params) == 0 {
+		return nil, fmt.Errorf("no model syntax found")
+	}
+
+	return addLavaanDefaults(params), nil
+}
+
+// lavaanParam is a parsed row that remembers whether it was written with modifiers
+type lavaanParam struct {
+	DataRow
+	modified bool
+}
+
+type lavaanStatement struct {
+	text string
+	line int
+}
+
+// lavaanStatements strips comments and splits the syntax into statements. A statement continues on the next line
+// if it ends in an operator or "+", or if the next line starts with "+" or "*"
+func lavaanStatements(src string) []lavaanStatement {
+	var res []lavaanStatement
+	var cur strings.Builder
+	curLine := 0
+
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			res = append(res, lavaanStatement{text: s, line: curLine})
+		}
+		cur.Reset()
+	}
+
+	for i, line := range strings.Split(src, "\n") {
+		if j := strings.IndexAny(line, "#!"); j >= 0 {
+			line = line[:j]
+		}
+
+		for k, part := range strings.Split(line, ";") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			prev := strings.TrimSpace(cur.String())
+			continues := k == 0 && prev != "" &&
+				(strings.HasPrefix(part, "+") || strings.HasPrefix(part, "*") || endsInOperator(prev) || !hasOperator(prev))
+			if !continues {
+				flush()
+				curLine = i + 1
+			}
+			cur.WriteString(" " + part)
+		}
+	}
+	flush()
+
+	return res
+}
+
+func endsInOperator(s string) bool {
+	if strings.HasSuffix(s, "+") || strings.HasSuffix(s, "*") {
+		return true
+	}
+	for _, op := range lavaanOps {
+		if strings.HasSuffix(s, op) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOperator(s string) bool {
+	_, _, _, ok := splitOperator(s)
+	return ok
+}
+
+// splitOperator finds the first operator outside of quotes and parentheses
+func splitOperator(s string) (lhs, op, rhs string, ok bool) {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			quoted = !quoted
+			continue
+		case '(':
+			depth++
+			continue
+		case ')':
+			depth--
+			continue
+		}
+		if quoted || depth > 0 {
+			continue
+		}
+
+		for _, o := range lavaanOps {
+			if strings.HasPrefix(s[i:], o) {
+				return strings.TrimSpace(s[:i]), o, strings.TrimSpace(s[i+len(o):]), true
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+func parseLavaanStatement(stmt string) ([]lavaanParam, error) {
+	lhs, op, rhs, ok := splitOperator(stmt)
+	if !ok {
+		return nil, fmt.Errorf("no operator in %q", stmt)
+	}
+	if lhs == "" || rhs == "" || endsInOperator(stmt) {
+		return nil, fmt.Errorf("incomplete statement %q", stmt)
+	}
+
+	switch op {
+	case ":=", "==", "<", ">":
+		// definitions and constraints are kept as written, the expressions are not evaluated
+		row := DataRow{Lhs: lhs, Op: op, Rhs: strings.Join(strings.Fields(rhs), " "), User: 1, Group: 1}
+		return []lavaanParam{{DataRow: row}}, nil
+	}
+
+	var lhsNames []string
+	for _, name := range splitTerms(lhs) {
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		lhsNames = append(lhsNames, name)
+	}
+
+	var params []lavaanParam
+	for _, term := range splitTerms(rhs) {
+		name, mods, err := parseTerm(term)
+		if err != nil {
+			return nil, err
+		}
+
+		rowOp := op
+		switch {
+		case name == "1" && op == "~":
+			rowOp = "~1"
+		case op == "|":
+			// thresholds are written t1 + t2 + ...
+			if !strings.HasPrefix(name, "t") {
+				return nil, fmt.Errorf("invalid threshold %q", term)
+			}
+		case !isIdentifier(name):
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+
+		for _, l := range lhsNames {
+			row := DataRow{Lhs: l, Op: rowOp, Rhs: name, User: 1, Group: 1}
+			if rowOp == "~1" {
+				row.Rhs = ""
+			}
+			if err := applyModifiers(&row, mods); err != nil {
+				return nil, fmt.Errorf("%s: %w", term, err)
+			}
+			params = append(params, lavaanParam{DataRow: row, modified: len(mods) > 0})
+		}
+	}
+
+	return params, nil
+}
+
+// splitTerms splits on "+" outside of parentheses
+func splitTerms(s string) []string {
+	var res []string
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '+':
+			if depth == 0 {
+				res = append(res, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	res = append(res, strings.TrimSpace(s[start:]))
+
+	return slices.DeleteFunc(res, func(t string) bool { return t == "" })
+}
+
+// parseTerm splits "mod1*mod2*name" into the name and its modifiers
+func parseTerm(term string) (name string, mods []string, err error) {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range term {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '*':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(term[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, strings.TrimSpace(term[start:]))
+
+	for _, p := range parts {
+		if p == "" {
+			return "", nil, fmt.Errorf("invalid term %q", term)
+		}
+	}
+
+	return parts[len(parts)-1], parts[:len(parts)-1], nil
+}
+
+// applyModifiers sets the fixed value and label of a row. Starting values are ignored, and of a vector of
+// modifiers given with c(...) only the first one is used since there is no data to tell the groups apart
+func applyModifiers(row *DataRow, mods []string) error {
+	for _, mod := range mods {
+		fn, arg := "", mod
+		if i := strings.Index(mod, "("); i > 0 && strings.HasSuffix(mod, ")") {
+			fn, arg = strings.TrimSpace(mod[:i]), strings.TrimSpace(mod[i+1:len(mod)-1])
+		}
+
+		if fn == "c" {
+			arg = strings.TrimSpace(strings.Split(arg, ",")[0])
+			fn = ""
+		}
+
+		switch fn {
+		case "start":
+			continue
+		case "label", "equal":
+			row.Label = unquote(arg)
+			continue
+		case "":
+		default:
+			return fmt.Errorf("unknown modifier %q", mod)
+		}
+
+		switch {
+		case arg == "NA":
+			// explicitly free
+		case isNumber(arg):
+			row.Est, _ = strconv.ParseFloat(arg, 64)
+		case isIdentifier(unquote(arg)):
+			row.Label = unquote(arg)
+		default:
+			return fmt.Errorf("invalid modifier %q", mod)
+		}
+	}
+	return nil
+}
+
+// addLavaanDefaults adds the parameters lavaan includes in a model without them being written
+func addLavaanDefaults(params []lavaanParam) []DataRow {
+	var vars []string
+	addVar := func(name string) {
+		if name != "" && !slices.Contains(vars, name) {
+			vars = append(vars, name)
+		}
+	}
+
+	// scale latent variables and composites by fixing their first loading or weight
+	seenLatent := make(map[string]bool)
+	rows := make([]DataRow, 0, len(params))
+	for _, p := range params {
+		if p.Op == "=~" || p.Op == "<~" {
+			if !seenLatent[p.Op+p.Lhs] && !p.modified {
+				p.Est = 1
+			}
+			seenLatent[p.Op+p.Lhs] = true
+		}
+		rows = append(rows, p.DataRow)
+	}
+
+	hasVariance := make(map[string]bool)
+	for _, row := range rows {
+		switch row.Op {
+		case "=~", "~", "~~", "<~":
+			addVar(row.Lhs)
+			addVar(row.Rhs)
+		case "~1", "|":
+			addVar(row.Lhs)
+		}
+		if row.Op == "~~" && row.Lhs == row.Rhs {
+			hasVariance[row.Lhs] = true
+		}
+	}
+
+	for _, v := range vars {
+		if !hasVariance[v] {
+			rows = append(rows, DataRow{Lhs: v, Op: "~~", Rhs: v, Group: 1})
+		}
+	}
+
+	return rows
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '.' || r == '_':
+		case unicode.IsDigit(r) && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,475 @@
+package read_write
+
+import (
+	"fmt"
+	"main/model"
+	"main/utils"
+	"math"
+	"slices"
+	"strings"
+)
+
+type InitialLayout int
+
+const (
+	LAYERED_HORIZONTAL InitialLayout = iota // exogenous variables on the left, endogenous on the right
+	LAYERED_VERTICAL                        // exogenous variables on top, endogenous below
+	FORCE_DIRECTED
+)
+
+const (
+	layerGapHorizontal float32 = 220 // distance between layers when they are columns
+	layerGapVertical   float32 = 160 // distance between layers when they are rows
+	nodeGapHorizontal  float32 = 20  // space between the nodes of a column
+	nodeGapVertical    float32 = 40  // space between the nodes of a row
+	layerClearance     float32 = 80  // space between the nodes of neighbouring layers, leaving room for the estimates
+	crossingSweeps             = 12
+	refineIterations           = 20
+)
+
+func ParseInitialLayout(s string) (InitialLayout, error) {
+	switch strings.ToLower(s) {
+	case "layered", "layered-horizontal":
+		return LAYERED_HORIZONTAL, nil
+	case "layered-vertical":
+		return LAYERED_VERTICAL, nil
+	case "force", "force-directed":
+		return FORCE_DIRECTED, nil
+	}
+	return 0, fmt.Errorf("unknown layout %q (expected layered, layered-vertical or force)", s)
+}
+
+// vertex is a node of the layered graph. Dummy vertices (node == nil) break up edges spanning several layers
+type vertex struct {
+	node  *model.Node
+	layer int
+	order float32
+	perp  float32 // position perpendicular to the layer direction
+	up    []*vertex
+	down  []*vertex
+}
+
+// layeredLayout arranges the visible nodes in layers following the direction of the regressions and loadings
+// (see Sugiyama, Tagawa & Toda, 1981). Indicators are kept in their own layer next to their latent variable.
+func layeredLayout(m *model.Model, vertical bool) {
+	nodes := make([]*model.Node, 0, len(m.Nodes))
+	intercepts := make([]*model.Node, 0)
+	for _, n := range m.Nodes {
+		switch {
+		case !n.Visible:
+		case n.Class == model.INTERCEPT:
+			// intercepts are placed next to their variable once the layout is done
+			intercepts = append(intercepts, n)
+		default:
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	// sort for a deterministic result, since the nodes come out of a map
+	slices.SortFunc(nodes, func(a, b *model.Node) int { return strings.Compare(a.VarName, b.VarName) })
+
+	// directed edges of the diagram; covariances do not imply a direction
+	succ := make(map[*model.Node][]*model.Node)
+	pred := make(map[*model.Node][]*model.Node)
+	for _, c := range m.Connections {
+		if c.Type != model.STRAIGHT || !c.Origin.Visible || !c.Destination.Visible || c.Origin.Class == model.INTERCEPT {
+			continue
+		}
+		succ[c.Origin] = append(succ[c.Origin], c.Destination)
+		pred[c.Destination] = append(pred[c.Destination], c.Origin)
+	}
+
+	// indicators only load on a single latent variable and do not predict anything. Formative indicators only form a
+	// single composite and are not predicted by anything
+	owner := make(map[*model.Node]*model.Node)
+	for _, n := range nodes {
+		if n.Class == model.OBSERVED && len(pred[n]) == 0 && len(succ[n]) == 1 && succ[n][0].Class == model.COMPOSITE {
+			owner[n] = succ[n][0]
+			continue
+		}
+		if n.Class != model.OBSERVED || len(succ[n]) > 0 {
+			continue
+		}
+		var latents []*model.Node
+		for _, p := range pred[n] {
+			if p.Class == model.LATENT {
+				latents = append(latents, p)
+			}
+		}
+		if len(latents) == 1 && len(pred[n]) == 1 {
+			owner[n] = latents[0]
+		}
+	}
+
+	structural := make([]*model.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if owner[n] == nil {
+			structural = append(structural, n)
+		}
+	}
+
+	layerOf := assignLayers(structural, succ)
+
+	// every structural layer gets its own layer of indicators. Indicators of exogenous variables are placed on the
+	// outside of the diagram, all others after their latent variable
+	maxLayer := 0
+	for _, l := range layerOf {
+		maxLayer = max(maxLayer, l)
+	}
+	hasBefore := false
+	hasAfter := make([]bool, maxLayer+1)
+	for _, o := range owner {
+		if layerOf[o] == 0 {
+			hasBefore = true
+		} else {
+			hasAfter[layerOf[o]] = true
+		}
+	}
+
+	// map structural layers (and their indicator layers) onto consecutive layer indices
+	structIdx := make([]int, maxLayer+1)
+	afterIdx := make([]int, maxLayer+1)
+	idx := 0
+	if hasBefore {
+		idx++
+	}
+	for l := 0; l <= maxLayer; l++ {
+		structIdx[l] = idx
+		idx++
+		if hasAfter[l] {
+			afterIdx[l] = idx
+			idx++
+		}
+	}
+	layers := make([][]*vertex, idx)
+
+	vertices := make(map[*model.Node]*vertex)
+	for _, n := range nodes {
+		v := &vertex{node: n}
+		switch o := owner[n]; {
+		case o == nil:
+			v.layer = structIdx[layerOf[n]]
+		case layerOf[o] == 0:
+			v.layer = 0
+		default:
+			v.layer = afterIdx[layerOf[o]]
+		}
+		vertices[n] = v
+		layers[v.layer] = append(layers[v.layer], v)
+	}
+
+	// connect the vertices, inserting dummies on edges that skip layers
+	for _, n := range nodes {
+		for _, s := range succ[n] {
+			a, b := vertices[n], vertices[s]
+			if a.layer == b.layer {
+				continue
+			}
+			if a.layer > b.layer {
+				a, b = b, a
+			}
+			prev := a
+			for l := a.layer + 1; l < b.layer; l++ {
+				d := &vertex{layer: l}
+				layers[l] = append(layers[l], d)
+				prev.down = append(prev.down, d)
+				d.up = append(d.up, prev)
+				prev = d
+			}
+			prev.down = append(prev.down, b)
+			b.up = append(b.up, prev)
+		}
+	}
+
+	orderLayers(layers)
+
+	// assign coordinates. Nodes take up their height within a column and their width within a row
+	nodeDim := layoutNodeDim(m)
+	extent := func(n *model.Node) float32 {
+		dim := defaultNodeDim // dummy vertices keep room for the estimate on their edge
+		if n != nil {
+			dim = nodeDim(n)
+		}
+		if vertical {
+			return dim.W
+		}
+		return dim.H
+	}
+	depth := func(n *model.Node) float32 {
+		if vertical {
+			return nodeDim(n).H
+		}
+		return nodeDim(n).W
+	}
+	gap, layerGap := nodeGapHorizontal, layerGapHorizontal
+	if vertical {
+		gap, layerGap = nodeGapVertical, layerGapVertical
+	}
+	placeLayers(layers, func(a, b *vertex) float32 {
+		return (extent(a.node)+extent(b.node))/2 + gap
+	})
+
+	// layers of large nodes are moved further apart
+	halfDepth := make([]float32, len(layers))
+	for l, layer := range layers {
+		for _, v := range layer {
+			if v.node != nil {
+				halfDepth[l] = max(halfDepth[l], depth(v.node)/2)
+			}
+		}
+	}
+	layerPos := make([]float32, len(layers))
+	for l := 1; l < len(layers); l++ {
+		layerPos[l] = layerPos[l-1] + max(layerGap, halfDepth[l-1]+halfDepth[l]+layerClearance)
+	}
+
+	for _, layer := range layers {
+		for _, v := range layer {
+			if v.node == nil {
+				continue
+			}
+			pos := utils.LocalPos{X: layerPos[v.layer], Y: v.perp}
+			if vertical {
+				pos = utils.LocalPos{X: v.perp, Y: layerPos[v.layer]}
+			}
+			v.node.Pos = utils.SnapToGrid(pos, 20)
+		}
+	}
+
+	// intercepts go between their variable and the previous layer
+	for _, c := range m.Connections {
+		if c.Origin.Class != model.INTERCEPT || !slices.Contains(intercepts, c.Origin) {
+			continue
+		}
+		// beside their variable, reaching halfway into the gap to the next node
+		side := (extent(c.Destination) + gap) / 2
+		offset := utils.LocalPos{X: -layerGap / 2, Y: side}
+		if vertical {
+			offset = utils.LocalPos{X: side, Y: -layerGap / 2}
+		}
+		c.Origin.Pos = utils.SnapToGrid(c.Destination.Pos.Add(offset), 20)
+	}
+}
+
+// assignLayers places every structural node one layer after its furthest predecessor. Feedback loops are broken by
+// ignoring the edges that close a cycle.
+func assignLayers(structural []*model.Node, succ map[*model.Node][]*model.Node) map[*model.Node]int {
+	isStructural := make(map[*model.Node]bool)
+	for _, n := range structural {
+		isStructural[n] = true
+	}
+
+	// depth-first search to find a topological order without the back edges
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := make(map[*model.Node]int)
+	topo := make([]*model.Node, 0, len(structural))
+	acyclic := make(map[*model.Node][]*model.Node)
+	var visit func(n *model.Node)
+	visit = func(n *model.Node) {
+		state[n] = active
+		for _, s := range succ[n] {
+			if !isStructural[s] {
+				continue
+			}
+			switch state[s] {
+			case unvisited:
+				acyclic[n] = append(acyclic[n], s)
+				visit(s)
+			case done:
+				acyclic[n] = append(acyclic[n], s)
+			}
+			// edges to active nodes close a cycle and are dropped
+		}
+		state[n] = done
+		topo = append(topo, n)
+	}
+	for _, n := range structural {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+	slices.Reverse(topo)
+
+	layerOf := make(map[*model.Node]int)
+	for _, n := range topo {
+		for _, s := range acyclic[n] {
+			layerOf[s] = max(layerOf[s], layerOf[n]+1)
+		}
+	}
+
+	// pull sources up to the layer right before their first successor so that
+	// exogenous variables sit next to what they predict
+	for _, n := range structural {
+		if len(acyclic[n]) == 0 || !isSource(n, acyclic) {
+			continue
+		}
+		next := math.MaxInt
+		for _, s := range acyclic[n] {
+			next = min(next, layerOf[s])
+		}
+		layerOf[n] = max(layerOf[n], next-1)
+	}
+
+	return layerOf
+}
+
+func isSource(n *model.Node, acyclic map[*model.Node][]*model.Node) bool {
+	for _, succs := range acyclic {
+		if slices.Contains(succs, n) {
+			return false
+		}
+	}
+	return true
+}
+
+// orderLayers reduces edge crossings with alternating barycenter sweeps, keeping the best ordering found
+func orderLayers(layers [][]*vertex) {
+	for _, layer := range layers {
+		for i, v := range layer {
+			v.order = float32(i)
+		}
+	}
+
+	best := snapshotOrder(layers)
+	bestCrossings := countCrossings(layers)
+	for i := 0; i < crossingSweeps && bestCrossings > 0; i++ {
+		if i%2 == 0 {
+			for l := 1; l < len(layers); l++ {
+				sortByBarycenter(layers[l], func(v *vertex) []*vertex { return v.up })
+			}
+		} else {
+			for l := len(layers) - 2; l >= 0; l-- {
+				sortByBarycenter(layers[l], func(v *vertex) []*vertex { return v.down })
+			}
+		}
+
+		if c := countCrossings(layers); c < bestCrossings {
+			bestCrossings = c
+			best = snapshotOrder(layers)
+		}
+	}
+
+	for l := range layers {
+		layers[l] = best[l]
+		for i, v := range layers[l] {
+			v.order = float32(i)
+		}
+	}
+}
+
+func sortByBarycenter(layer []*vertex, neighbours func(v *vertex) []*vertex) {
+	bary := make(map[*vertex]float32, len(layer))
+	for _, v := range layer {
+		nb := neighbours(v)
+		if len(nb) == 0 {
+			// vertices without neighbours keep their place
+			bary[v] = v.order
+			continue
+		}
+		var sum float32
+		for _, u := range nb {
+			sum += u.order
+		}
+		bary[v] = sum / float32(len(nb))
+	}
+
+	slices.SortStableFunc(layer, func(a, b *vertex) int {
+		switch {
+		case bary[a] < bary[b]:
+			return -1
+		case bary[a] > bary[b]:
+			return 1
+		}
+		return 0
+	})
+	for i, v := range layer {
+		v.order = float32(i)
+	}
+}
+
+func snapshotOrder(layers [][]*vertex) [][]*vertex {
+	res := make([][]*vertex, len(layers))
+	for l, layer := range layers {
+		res[l] = slices.Clone(layer)
+	}
+	return res
+}
+
+func countCrossings(layers [][]*vertex) int {
+	crossings := 0
+	for l := 0; l < len(layers)-1; l++ {
+		type edge struct{ a, b float32 }
+		var edges []edge
+		for _, v := range layers[l] {
+			for _, d := range v.down {
+				edges = append(edges, edge{v.order, d.order})
+			}
+		}
+		for i := range edges {
+			for j := i + 1; j < len(edges); j++ {
+				if (edges[i].a-edges[j].a)*(edges[i].b-edges[j].b) < 0 {
+					crossings++
+				}
+			}
+		}
+	}
+	return crossings
+}
+
+// placeLayers spaces the vertices of each layer at least the separation of each neighbouring pair apart, then repeatedly
+// moves every vertex toward the average of its neighbours without changing the order within a layer
+func placeLayers(layers [][]*vertex, separation func(a, b *vertex) float32) {
+	for _, layer := range layers {
+		var pos float32
+		for i, v := range layer {
+			if i > 0 {
+				pos += separation(layer[i-1], v)
+			}
+			v.perp = pos
+		}
+		// center the layer
+		for _, v := range layer {
+			v.perp -= pos / 2
+		}
+	}
+
+	for i := 0; i < refineIterations; i++ {
+		for _, layer := range layers {
+			desired := make([]float32, len(layer))
+			for j, v := range layer {
+				nb := append(slices.Clone(v.up), v.down...)
+				if len(nb) == 0 {
+					desired[j] = v.perp
+					continue
+				}
+				var sum float32
+				for _, u := range nb {
+					sum += u.perp
+				}
+				desired[j] = sum / float32(len(nb))
+			}
+
+			// push apart in both directions and meet in the middle to keep the layer centered on its neighbours
+			fwd := slices.Clone(desired)
+			for j := 1; j < len(fwd); j++ {
+				fwd[j] = max(fwd[j], fwd[j-1]+separation(layer[j-1], layer[j]))
+			}
+			bwd := slices.Clone(desired)
+			for j := len(bwd) - 2; j >= 0; j-- {
+				bwd[j] = min(bwd[j], bwd[j+1]-separation(layer[j], layer[j+1]))
+			}
+			for j, v := range layer {
+				v.perp = (fwd[j] + bwd[j]) / 2
+			}
+			for j := 1; j < len(layer); j++ {
+				layer[j].perp = max(layer[j].perp, layer[j-1].perp+separation(layer[j-1], layer[j]))
+			}
+		}
+	}
+}
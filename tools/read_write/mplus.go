@@ -0,0 +1,243 @@
+package read_write
+
+import (
+	"fmt"
+	"main/model"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Mplus prints this value for statistics that do not apply, e.g. the p-value of a fixed parameter
+const mplusMissing = 999
+
+// ReadMplus reads the MODEL RESULTS of an Mplus output file, or the STDYX standardized results if standardized is set
+func ReadMplus(path string, standardized bool) ([]DataRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ParseMplus(string(data), standardized)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// ParseMplusFit reads the MODEL FIT INFORMATION of an Mplus output into the fit indices named as in lavaan
+func ParseMplusFit(out string) model.FitMeasures {
+	lines := strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	start := slices.IndexFunc(lines, func(line string) bool { return strings.TrimRight(line, " ") == "MODEL FIT INFORMATION" })
+	if start < 0 {
+		return nil
+	}
+
+	fit := make(model.FitMeasures)
+	var section string
+	for _, line := range lines[start+1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// subsections such as "CFI/TLI" start at the beginning of the line
+		if line[0] != ' ' {
+			if strings.TrimRight(line, " ") == "MODEL RESULTS" {
+				break
+			}
+			section = strings.TrimSpace(line)
+			continue
+		}
+
+		// values of robust estimators are marked with an asterisk
+		fields := strings.Fields(strings.ReplaceAll(line, "*", ""))
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			continue
+		}
+		name := strings.Join(fields[:len(fields)-1], " ")
+
+		switch {
+		case section == "Chi-Square Test of Model Fit":
+			switch name {
+			case "Value":
+				fit["chisq"] = value
+			case "Degrees of Freedom":
+				fit["df"] = value
+			case "P-Value":
+				fit["pvalue"] = value
+			}
+		case strings.HasPrefix(section, "RMSEA"):
+			switch {
+			case name == "Estimate":
+				fit["rmsea"] = value
+			case strings.Contains(name, "C.I."):
+				// the line ends with both bounds
+				lower, err := strconv.ParseFloat(fields[len(fields)-2], 64)
+				if err == nil {
+					fit["rmsea.ci.lower"], fit["rmsea.ci.upper"] = lower, value
+				}
+			}
+		case section == "CFI/TLI" && (name == "CFI" || name == "TLI"):
+			fit[strings.ToLower(name)] = value
+		case strings.HasPrefix(section, "SRMR") && name == "Value":
+			fit["srmr"] = value
+		}
+	}
+
+	if len(fit) == 0 {
+		return nil
+	}
+	return fit
+}
+
+// ParseMplus translates the results section of an Mplus output into the rows of a lavaan parameter table.
+// Variable names are kept as Mplus prints them. Groups are numbered in the order they appear, starting at one.
+// Confidence intervals are taken from the output of Bayesian models and calculated from the standard error otherwise.
+func ParseMplus(out string, standardized bool) ([]DataRow, error) {
+	heading := "MODEL RESULTS"
+	if standardized {
+		heading = "STDYX Standardization"
+	}
+
+	lines := strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimRight(line, " ") == heading {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("no %s section found", heading)
+	}
+
+	var rows []DataRow
+	group := 1
+	seenGroup := false
+	bayesian := false
+	var lhs, op string
+
+	for _, line := range lines[start:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// headings at the start of a line end the section, except for the groups within it
+		if line[0] != ' ' {
+			if name, ok := strings.CutPrefix(line, "Group "); ok && name != "" {
+				if seenGroup {
+					group++
+				}
+				seenGroup = true
+				continue
+			}
+			break
+		}
+
+		fields := strings.Fields(line)
+		if strings.Contains(line, "Posterior") {
+			bayesian = true
+		}
+
+		// parameter lines hold a name followed by numbers, anything else starts a block of parameters
+		values, ok := parseMplusValues(fields[1:])
+		if !ok || len(values) == 0 {
+			lhs, op = mplusBlock(fields)
+			continue
+		}
+		if op == "" {
+			continue
+		}
+
+		row := DataRow{Op: op, Group: group, Est: values[0]}
+		switch op {
+		case "=~", "~", "~~":
+			row.Lhs, row.Rhs = lhs, fields[0]
+			row.User = 1
+		case "~1":
+			row.Lhs = fields[0]
+		case "~~var":
+			row.Op = "~~"
+			row.Lhs, row.Rhs = fields[0], fields[0]
+		case "|":
+			variable, threshold, found := strings.Cut(fields[0], "$")
+			if !found {
+				continue
+			}
+			row.Lhs, row.Rhs = variable, "t"+threshold
+		case ":=":
+			row.Lhs = fields[0]
+			row.User = 1
+		}
+
+		switch {
+		case bayesian && len(values) >= 5:
+			// estimate, posterior s.d., one-tailed p-value, lower and upper 2.5%
+			row.PValue = values[2]
+			row.CiLower, row.CiUpper = values[3], values[4]
+		case len(values) >= 4:
+			// estimate, s.e., est./s.e., two-tailed p-value
+			se := values[1]
+			if se != mplusMissing {
+				row.SE = se
+				row.CiLower, row.CiUpper = row.Est-1.96*se, row.Est+1.96*se
+			}
+			if values[3] != mplusMissing {
+				row.PValue = values[3]
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no parameters found in the %s section", heading)
+	}
+
+	return rows, nil
+}
+
+// mplusBlock reads the line that starts a block of parameters, returning the variable the block belongs to and the
+// lavaan operator of its parameters. Blocks that cannot be drawn get an empty operator
+func mplusBlock(fields []string) (lhs, op string) {
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "BY":
+			return fields[0], "=~"
+		case "ON":
+			return fields[0], "~"
+		case "WITH":
+			return fields[0], "~~"
+		}
+	}
+
+	switch strings.Join(fields, " ") {
+	case "Means", "Intercepts":
+		return "", "~1"
+	case "Variances", "Residual Variances":
+		return "", "~~var"
+	case "Thresholds":
+		return "", "|"
+	case "New/Additional Parameters":
+		return "", ":="
+	}
+
+	return "", ""
+}
+
+func parseMplusValues(fields []string) ([]float64, bool) {
+	values := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		// Bayesian output marks significant parameters with an asterisk
+		if f == "*" {
+			continue
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
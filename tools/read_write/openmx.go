@@ -0,0 +1,213 @@
+package read_write
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// RAMMatrix is one matrix of an OpenMx RAM model, stored row by row
+type RAMMatrix struct {
+	Values [][]float64 `json:"values"`
+	Free   [][]bool    `json:"free,omitempty"`
+	SE     [][]float64 `json:"se,omitempty"`
+	Labels [][]string  `json:"labels,omitempty"`
+}
+
+// RAMModel describes a fitted OpenMx RAM model. Variables names the columns of A, S and F and the entries of M.
+// A holds the paths from the column variable to the row variable, S the (co)variances, M the means and F the filter
+// that selects the observed variables. Multi-group models list one RAMModel per group in Groups instead.
+type RAMModel struct {
+	Variables []string   `json:"variables"`
+	A         RAMMatrix  `json:"A"`
+	S         RAMMatrix  `json:"S"`
+	F         RAMMatrix  `json:"F"`
+	M         *RAMMatrix `json:"M,omitempty"`
+	Groups    []RAMModel `json:"groups,omitempty"`
+}
+
+// ReadRAM reads an OpenMx RAM model from a JSON file
+func ReadRAM(path string) ([]DataRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ram RAMModel
+	if err := json.Unmarshal(data, &ram); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	rows, err := ram.Rows()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// isRAMJSON tells a RAM model apart from a parameter table, which is an array or an object holding the parameters
+func isRAMJSON(data []byte) bool {
+	if !isJSONObject(data) {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return true // reported when the RAM model is read
+	}
+	_, isTable := fields["parameters"]
+	return !isTable
+}
+
+func isJSONObject(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+// Rows translates the matrices into the rows of a lavaan parameter table. Every fixed non-zero or free entry becomes a
+// parameter. Paths out of a latent variable are loadings, so higher-order factors stay latent, and all other paths are
+// regressions.
+// Variances and means are treated as generated parameters like lavaan does for those that are not written out.
+// P-values and confidence intervals are calculated from the standard errors, and entries that are not free are marked as fixed.
+func (ram *RAMModel) Rows() ([]DataRow, error) {
+	groups := ram.Groups
+	if len(groups) == 0 {
+		groups = []RAMModel{*ram}
+	}
+
+	var rows []DataRow
+	for g, group := range groups {
+		groupRows, err := group.groupRows(g + 1)
+		if err != nil {
+			if len(groups) > 1 {
+				return nil, fmt.Errorf("group %d: %w", g+1, err)
+			}
+			return nil, err
+		}
+		rows = append(rows, groupRows...)
+	}
+	return rows, nil
+}
+
+func (ram *RAMModel) groupRows(group int) ([]DataRow, error) {
+	n := len(ram.Variables)
+	if n == 0 {
+		return nil, fmt.Errorf("no variables")
+	}
+	if err := ram.A.check("A", n, n); err != nil {
+		return nil, err
+	}
+	if err := ram.S.check("S", n, n); err != nil {
+		return nil, err
+	}
+	if len(ram.F.Values) == 0 {
+		return nil, fmt.Errorf("matrix F selects no observed variables")
+	}
+	if err := ram.F.check("F", len(ram.F.Values), n); err != nil {
+		return nil, err
+	}
+	if ram.M != nil {
+		if err := ram.M.check("M", 1, n); err != nil {
+			return nil, err
+		}
+	}
+
+	// a variable is observed if the filter selects it
+	observed := make([]bool, n)
+	for _, row := range ram.F.Values {
+		for j, v := range row {
+			if v != 0 {
+				observed[j] = true
+			}
+		}
+	}
+
+	var rows []DataRow
+	add := func(mat *RAMMatrix, i, j int, row DataRow) {
+		if !mat.isParameter(i, j) {
+			return
+		}
+		row.Group = group
+		row.Est = mat.Values[i][j]
+		row.Label = mat.label(i, j)
+		if se := mat.se(i, j); mat.isFree(i, j) && se > 0 {
+			row.SE = se
+			row.PValue = math.Erfc(math.Abs(row.Est/se) / math.Sqrt2)
+			row.CiLower, row.CiUpper = row.Est-1.96*se, row.Est+1.96*se
+		} else {
+			row.Fixed = !mat.isFree(i, j)
+			row.CiLower, row.CiUpper = row.Est, row.Est
+		}
+		rows = append(rows, row)
+	}
+
+	vars := ram.Variables
+	for i := range n {
+		for j := range n {
+			if i == j {
+				continue
+			}
+			// paths out of a latent variable are loadings, which also keeps higher-order factors latent
+			if !observed[j] {
+				add(&ram.A, i, j, DataRow{Lhs: vars[j], Op: "=~", Rhs: vars[i], User: 1})
+			} else {
+				add(&ram.A, i, j, DataRow{Lhs: vars[i], Op: "~", Rhs: vars[j], User: 1})
+			}
+		}
+	}
+
+	for i := range n {
+		// S is symmetric, so only its upper triangle is read
+		for j := i + 1; j < n; j++ {
+			add(&ram.S, i, j, DataRow{Lhs: vars[i], Op: "~~", Rhs: vars[j], User: 1})
+		}
+		add(&ram.S, i, i, DataRow{Lhs: vars[i], Op: "~~", Rhs: vars[i]})
+	}
+
+	if ram.M != nil {
+		for j := range n {
+			add(ram.M, 0, j, DataRow{Lhs: vars[j], Op: "~1"})
+		}
+	}
+
+	return rows, nil
+}
+
+func (mat *RAMMatrix) check(name string, rows, cols int) error {
+	if len(mat.Values) != rows {
+		return fmt.Errorf("matrix %s has %d rows instead of %d", name, len(mat.Values), rows)
+	}
+	for _, row := range mat.Values {
+		if len(row) != cols {
+			return fmt.Errorf("matrix %s has a row of %d values instead of %d", name, len(row), cols)
+		}
+	}
+	return nil
+}
+
+func (mat *RAMMatrix) isParameter(i, j int) bool {
+	return mat.isFree(i, j) || mat.Values[i][j] != 0
+}
+
+// isFree reports whether the entry was estimated. Without a free matrix, the entries with a standard error are
+func (mat *RAMMatrix) isFree(i, j int) bool {
+	if len(mat.Free) == 0 {
+		return mat.se(i, j) > 0
+	}
+	return i < len(mat.Free) && j < len(mat.Free[i]) && mat.Free[i][j]
+}
+
+func (mat *RAMMatrix) se(i, j int) float64 {
+	if i < len(mat.SE) && j < len(mat.SE[i]) {
+		return mat.SE[i][j]
+	}
+	return 0
+}
+
+func (mat *RAMMatrix) label(i, j int) string {
+	if i < len(mat.Labels) && j < len(mat.Labels[i]) {
+		return mat.Labels[i][j]
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package read_write
+
+import (
+	"main/model"
+	"main/utils"
+
+	"gioui.org/layout"
+)
+
+const (
+	placementGrid    float32 = 20
+	placementMargin  float32 = 40 // free space kept around a newly placed node
+	maxPlacementRing         = 100
+)
+
+// typical size of a node, which keeps unconnected nodes clear of the diagram
+var defaultNodeDim = utils.LocalDim{W: 100, H: 60}
+
+// placeNewNodes moves nodes that are not part of a saved layout next to their already positioned neighbours,
+// at the closest spot that does not overlap another node. Saved positions are left untouched.
+func placeNewNodes(m *model.Model, newNodes []*model.Node) {
+	isNew := make(map[*model.Node]bool)
+	for _, n := range newNodes {
+		isNew[n] = true
+	}
+
+	// anything already on the canvas is an obstacle
+	positioned := make(map[*model.Node]bool)
+	placed := make([]*model.Node, 0, len(m.Nodes))
+	for _, n := range m.Nodes {
+		if isNew[n] {
+			continue
+		}
+		positioned[n] = true
+		if n.Visible {
+			placed = append(placed, n)
+		}
+	}
+	if len(placed) == 0 {
+		return
+	}
+
+	nodeDim := layoutNodeDim(m)
+
+	neighbours := make(map[*model.Node][]*model.Node)
+	for _, c := range m.Connections {
+		if c.Origin == c.Destination {
+			continue
+		}
+		neighbours[c.Origin] = append(neighbours[c.Origin], c.Destination)
+		neighbours[c.Destination] = append(neighbours[c.Destination], c.Origin)
+	}
+
+	// nodes connected to the existing layout are placed first so that their own new neighbours can follow them
+	queue := make([]*model.Node, 0, len(newNodes))
+	queued := make(map[*model.Node]bool)
+	for len(queue) < len(newNodes) {
+		progress := false
+		for _, n := range newNodes {
+			if queued[n] {
+				continue
+			}
+			for _, nb := range neighbours[n] {
+				if positioned[nb] || queued[nb] {
+					queue = append(queue, n)
+					queued[n] = true
+					progress = true
+					break
+				}
+			}
+		}
+		if !progress {
+			// the remaining nodes are not connected to anything placed
+			for _, n := range newNodes {
+				if !queued[n] {
+					queue = append(queue, n)
+					queued[n] = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, n := range queue {
+		var anchors []*model.Node
+		for _, nb := range neighbours[n] {
+			if positioned[nb] {
+				anchors = append(anchors, nb)
+			}
+		}
+
+		var target utils.LocalPos
+		if len(anchors) > 0 {
+			for _, a := range anchors {
+				target = target.Add(a.Pos)
+			}
+			target = target.Div(float32(len(anchors)))
+		} else {
+			// unconnected nodes go to the right of the diagram
+			rect := layoutBounds(placed, nodeDim)
+			target = utils.LocalPos{X: rect.SE.X + defaultNodeDim.W, Y: (rect.NW.Y + rect.SE.Y) / 2}
+		}
+
+		n.Pos = findFreeSpot(target, nodeDim(n), placed, nodeDim)
+		positioned[n] = true
+		if n.Visible {
+			placed = append(placed, n)
+		}
+	}
+}
+
+// layoutNodeDim returns the size of a node in device-independent units. Saved dimensions are in pixels of the screen
+// the layout was edited on, and nodes that have not been drawn yet are sized to their text
+func layoutNodeDim(m *model.Model) func(*model.Node) utils.LocalDim {
+	pxPerDp := m.PxPerDp
+	if pxPerDp == 0 {
+		pxPerDp = 1
+	}
+	return func(n *model.Node) utils.LocalDim {
+		if n.Dim.W == 0 || n.Dim.H == 0 {
+			return m.NodeDim(n, layout.Context{})
+		}
+		return n.Dim.Div(pxPerDp)
+	}
+}
+
+// findFreeSpot searches outward from target in rings of grid cells and returns the closest free position
+func findFreeSpot(target utils.LocalPos, dim utils.LocalDim, placed []*model.Node, nodeDim func(*model.Node) utils.LocalDim) utils.LocalPos {
+	center := utils.SnapToGrid(target, placementGrid)
+
+	for ring := 0; ring <= maxPlacementRing; ring++ {
+		var best utils.LocalPos
+		found := false
+		bestDist := float32(0)
+
+		for i := -ring; i <= ring; i++ {
+			for j := -ring; j <= ring; j++ {
+				// only the outline of the ring, the inside has been searched already
+				if max(abs(i), abs(j)) != ring {
+					continue
+				}
+
+				pos := center.Add(utils.LocalPos{X: float32(i) * placementGrid, Y: float32(j) * placementGrid})
+				if overlapsAny(pos, dim, placed, nodeDim) {
+					continue
+				}
+
+				dist := utils.DistLoc(pos, target)
+				if !found || dist < bestDist {
+					best, bestDist, found = pos, dist, true
+				}
+			}
+		}
+
+		if found {
+			return best
+		}
+	}
+
+	return center
+}
+
+func overlapsAny(pos utils.LocalPos, dim utils.LocalDim, placed []*model.Node, nodeDim func(*model.Node) utils.LocalDim) bool {
+	rect := nodeRect(pos, dim).Expand(placementMargin)
+	for _, p := range placed {
+		if rect.Intersects(nodeRect(p.Pos, nodeDim(p))) {
+			return true
+		}
+	}
+	return false
+}
+
+func layoutBounds(nodes []*model.Node, nodeDim func(*model.Node) utils.LocalDim) utils.LocalRect {
+	rect := nodeRect(nodes[0].Pos, nodeDim(nodes[0]))
+	for _, n := range nodes[1:] {
+		r := nodeRect(n.Pos, nodeDim(n))
+		rect.NW = utils.LocalPos{X: min(rect.NW.X, r.NW.X), Y: min(rect.NW.Y, r.NW.Y)}
+		rect.SE = utils.LocalPos{X: max(rect.SE.X, r.SE.X), Y: max(rect.SE.Y, r.SE.Y)}
+	}
+	return rect
+}
+
+func nodeRect(pos utils.LocalPos, dim utils.LocalDim) utils.LocalRect {
+	return utils.LocalRect{NW: pos.SubDim(dim.Div(2)), SE: pos.AddDim(dim.Div(2))}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
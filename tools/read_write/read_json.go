@@ -1,7 +1,10 @@
 package read_write
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"image/color"
 	"log"
 	"main/model"
@@ -10,6 +13,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 const (
@@ -29,9 +33,50 @@ type DataRow struct {
 	PValue  float64 `json:"pvalue"`
 	CiLower float64 `json:"ci_lower"`
 	CiUpper float64 `json:"ci_upper"`
+	Median  float64 `json:"median"` // posterior median of Bayesian models
+	Rhat    float64 `json:"rhat"`   // potential scale reduction factor of Bayesian models
+	SE      float64 `json:"se"`
+	Fixed   bool    `json:"fixed"` // set by inputs that tell fixed parameters apart, the others leave their p-value at zero
+
+	// the standardized solution (lavaan's std.all), left at zero if the input only has one solution
+	StdAll     float64 `json:"std_all"`
+	StdSE      float64 `json:"std_se"`
+	StdPValue  float64 `json:"std_pvalue"`
+	StdCiLower float64 `json:"std_ci_lower"`
+	StdCiUpper float64 `json:"std_ci_upper"`
 }
 
-func ModelFromJSON(dir, projectName string) *model.Model {
+// estimate returns the estimate of the row, along with its standardized solution if it has one
+func (row DataRow) estimate() model.Estimate {
+	e := model.Estimate{
+		Est:    row.Est,
+		PValue: row.PValue,
+		CI:     [2]float64{row.CiLower, row.CiUpper},
+		Label:  row.Label,
+		Median: row.Median,
+		Rhat:   row.Rhat,
+		SE:     row.SE,
+		Fixed:  row.Fixed,
+	}
+	if row.StdAll != 0 || row.StdSE != 0 || row.StdCiLower != 0 || row.StdCiUpper != 0 {
+		e.Std = &model.Estimate{
+			Est:    row.StdAll,
+			PValue: row.StdPValue,
+			CI:     [2]float64{row.StdCiLower, row.StdCiUpper},
+			Label:  row.Label,
+			SE:     row.StdSE,
+		}
+	}
+	return e
+}
+
+func ModelFromJSON(dir, projectName string, initialLayout InitialLayout) *model.Model {
+	rows := readJSON(filepath.Join(dir, "temp.json"))
+	return ModelFromRows(rows, dir, projectName, initialLayout)
+}
+
+// ModelFromRows builds the model of a parameter table, keeping the positions of the layout saved as projectName in dir
+func ModelFromRows(rows []DataRow, dir, projectName string, initialLayout InitialLayout) *model.Model {
 	m := new(model.Model)
 	// Check for existing project
 	projPath := filepath.Join(dir, projectName+".json")
@@ -42,9 +87,6 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 		loadedProj = true
 	}
 
-	tempPath := filepath.Join(dir, "temp.json")
-	rows := readJSON(tempPath)
-
 	// translate data to model type
 	varMap := make(map[string]*model.Node)
 
@@ -55,10 +97,46 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 	}
 
 	connections := make([]*model.Connection, 0)
+	// multi-group models repeat each parameter once per group, so connections are keyed by parameter
+	connMap := make(map[string]*model.Connection)
+	groups := make([]int, 0)
+	newNodes := make([]*model.Node, 0)
 	randMag := float32(2000)
 	var i int
+	var thresholdRows, r2Rows []DataRow
+	var definedParams []*model.DefinedParameter
 	for _, row := range rows {
-		if !(row.Op == "=~" || row.Op == "~~" || row.Op == "~") {
+		if row.Op == "|" {
+			// thresholds are attached to their variable once all nodes exist
+			thresholdRows = append(thresholdRows, row)
+			continue
+		}
+		if row.Op == "r2" {
+			// like thresholds, the variance explained belongs to a node
+			r2Rows = append(r2Rows, row)
+			continue
+		}
+		if row.Op == ":=" {
+			definedParams = addDefinedParameter(definedParams, row)
+			continue
+		}
+		if !(row.Op == "=~" || row.Op == "<~" || row.Op == "~~" || row.Op == "~" || row.Op == "~1") {
+			continue
+		}
+
+		if !slices.Contains(groups, row.Group) {
+			groups = append(groups, row.Group)
+		}
+
+		estimate := row.estimate()
+
+		// the rows of every group are drawn as one connection
+		key := fmt.Sprintf("%s %s %s", row.Lhs, row.Op, row.Rhs)
+		if c, ok := connMap[key]; ok {
+			c.GroupEstimates[row.Group] = estimate
+			if row.User == 1 {
+				c.UserDefined = true
+			}
 			continue
 		}
 
@@ -68,14 +146,31 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 			pos := utils.LocalPos{X: (rand.Float32() - .5) * randMag, Y: (rand.Float32() - .5) * randMag}
 			lhs.Pos = utils.SnapToGrid(pos, 20)
 			lhs.Col = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			newNodes = append(newNodes, lhs)
 			i++
+			// register right away so that a variance of a new variable refers to the same node on both sides
+			varMap[row.Lhs] = lhs
 		}
-		rhs, ok := varMap[row.Rhs]
+
+		// intercept rows have no rhs, so every intercept gets its own node named after its variable
+		rhsName := row.Rhs
+		rhsText := row.Rhs
+		if row.Op == "~1" {
+			rhsName = row.Lhs + "~1"
+			rhsText = "1"
+		}
+
+		rhs, ok := varMap[rhsName]
 		if !ok {
 			rhs = new(model.Node)
 			pos := utils.LocalPos{X: (rand.Float32() - .5) * randMag, Y: (rand.Float32() - .5) * randMag}
+			if row.Op == "~1" {
+				// start intercepts just below their variable
+				pos = lhs.Pos.Add(utils.LocalPos{Y: 100})
+			}
 			rhs.Pos = utils.SnapToGrid(pos, 20)
 			rhs.Col = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			newNodes = append(newNodes, rhs)
 			i++
 		}
 
@@ -86,14 +181,18 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 
 		// set var names (init to be same as text for now)
 		lhs.VarName = row.Lhs
-		rhs.VarName = row.Rhs
+		rhs.VarName = rhsName
 		lhs.Text = row.Lhs
-		rhs.Text = row.Rhs
+		rhs.Text = rhsText
 
 		// Set estimate values
 		c.Est = row.Est
 		c.PValue = row.PValue
 		c.CI = [2]float64{row.CiLower, row.CiUpper}
+		c.Label = row.Label
+		c.Median = row.Median
+		c.Rhat = row.Rhat
+		c.GroupEstimates = map[int]model.Estimate{row.Group: estimate}
 
 		// define connection and node types
 		switch row.Op {
@@ -102,6 +201,12 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 			c.Type = model.STRAIGHT
 			c.Origin = lhs
 			c.Destination = rhs
+		case "<~":
+			// formative indicators point into the composite they form
+			lhs.Class = model.COMPOSITE
+			c.Type = model.STRAIGHT
+			c.Origin = rhs
+			c.Destination = lhs
 		case "~~":
 			if lhs.VarName == rhs.VarName {
 				c.Type = model.CIRCULAR
@@ -114,6 +219,11 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 			c.Type = model.STRAIGHT
 			c.Origin = rhs
 			c.Destination = lhs
+		case "~1":
+			rhs.Class = model.INTERCEPT
+			c.Type = model.STRAIGHT
+			c.Origin = rhs
+			c.Destination = lhs
 		default:
 			continue
 		}
@@ -122,7 +232,7 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 			c.UserDefined = true
 		}
 
-		if lhs.Class != model.LATENT {
+		if lhs.Class != model.LATENT && lhs.Class != model.COMPOSITE {
 			lhs.Class = model.OBSERVED
 		}
 
@@ -139,7 +249,7 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 
 		//assign nodes to map
 		varMap[row.Lhs] = lhs
-		varMap[row.Rhs] = rhs
+		varMap[rhsName] = rhs
 		// assign connection to array
 
 		// check of connection already exists. Match label placements
@@ -153,14 +263,65 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 			}
 		}
 
+		connMap[key] = c
 		connections = append(connections, c)
 
 	}
 
+	for _, n := range varMap {
+		n.Thresholds = nil
+		n.GroupR2 = nil
+	}
+	for _, row := range r2Rows {
+		n, ok := varMap[row.Lhs]
+		if !ok {
+			continue
+		}
+		if n.GroupR2 == nil {
+			n.GroupR2 = make(map[int]float64)
+			n.R2 = row.Est
+		}
+		n.GroupR2[row.Group] = row.Est
+	}
+	for _, row := range thresholdRows {
+		n, ok := varMap[row.Lhs]
+		if !ok {
+			continue
+		}
+
+		estimate := model.Estimate{
+			Est:    row.Est,
+			PValue: row.PValue,
+			CI:     [2]float64{row.CiLower, row.CiUpper},
+		}
+
+		idx := slices.IndexFunc(n.Thresholds, func(t *model.Threshold) bool { return t.Name == row.Rhs })
+		if idx < 0 {
+			n.Thresholds = append(n.Thresholds, &model.Threshold{Name: row.Rhs, Est: row.Est, GroupEstimates: map[int]model.Estimate{}})
+			idx = len(n.Thresholds) - 1
+		}
+		n.Thresholds[idx].GroupEstimates[row.Group] = estimate
+	}
+
+	m.DefinedParams = definedParams
+	m.Bayesian = isBayesian(rows)
+
 	if mExisting != nil {
 		m.CoeffDisplay = mExisting.CoeffDisplay
+		m.ThresholdDisplay = mExisting.ThresholdDisplay
+		m.R2Display = mExisting.R2Display
+		m.Solution = mExisting.Solution
+		m.LabelTemplate = mExisting.LabelTemplate
+		m.Precision = mExisting.Precision
+		m.LabelDisplay = mExisting.LabelDisplay
+		m.HighlightConstraints = mExisting.HighlightConstraints
+		m.EffectsDisplay = mExisting.EffectsDisplay
+		m.EffectsPos = mExisting.EffectsPos
+		m.ShowFit = mExisting.ShowFit
+		m.FitPos = mExisting.FitPos
 		m.Font = mExisting.Font
 		m.PxPerDp = mExisting.PxPerDp
+		m.GroupPanels = mExisting.GroupPanels
 	} else {
 		m.Font = model.FontSettings{
 			Family: "sans",
@@ -168,19 +329,101 @@ func ModelFromJSON(dir, projectName string) *model.Model {
 			Face:   utils.LoadSansFontFace()[0],
 		}
 		m.CoeffDisplay = utils.STAR
+		if !hasEstimates(rows) {
+			// e.g. a model drawn from syntax before any data exist
+			m.CoeffDisplay = utils.NONE
+		}
+	}
+	// a layout may be refitted with the other kind of estimation
+	switch {
+	case m.Bayesian && m.CoeffDisplay != utils.NONE && !m.CoeffDisplay.IsPosterior():
+		m.CoeffDisplay = utils.POSTERIOR_MEAN
+	case !m.Bayesian && m.CoeffDisplay.IsPosterior():
+		m.CoeffDisplay = utils.STAR
+	}
+
+	// intercepts are only shown together with their connection
+	for _, c := range connections {
+		if c.Origin.Class == model.INTERCEPT {
+			c.Origin.Visible = c.UserDefined || m.ViewGenerated
+		}
 	}
 
 	m.Connections = connections
 	m.Nodes = utils.MapValsToSlice(varMap)
 	m.Network = CalculateNodeNetwork(connections)
 
+	// keep the previously active group if it still exists
+	slices.Sort(groups)
+	m.Groups = groups
+	if len(groups) > 0 {
+		activeGroup := groups[0]
+		if mExisting != nil && slices.Contains(groups, mExisting.ActiveGroup) {
+			activeGroup = mExisting.ActiveGroup
+		}
+		m.SetActiveGroup(activeGroup)
+	}
+	// a layout may be refitted without the standardized solution or the standard errors
+	if !slices.Contains(m.Solutions(), m.Solution) {
+		m.Solution = model.SOLUTION_UNSTANDARDIZED
+	}
+
 	if !loadedProj {
-		forceDirectNodes(m)
+		ArrangeModel(m, initialLayout)
+	} else {
+		placeNewNodes(m, newNodes)
 	}
 
 	return m
 }
 
+// addDefinedParameter adds the estimate of a ":=" row to its parameter, adding the parameter if it is new
+func addDefinedParameter(params []*model.DefinedParameter, row DataRow) []*model.DefinedParameter {
+	estimate := row.estimate()
+
+	idx := slices.IndexFunc(params, func(p *model.DefinedParameter) bool { return p.Name == row.Lhs })
+	if idx < 0 {
+		params = append(params, &model.DefinedParameter{
+			Name:           row.Lhs,
+			Expr:           row.Rhs,
+			Est:            estimate.Est,
+			PValue:         estimate.PValue,
+			CI:             estimate.CI,
+			GroupEstimates: map[int]model.Estimate{},
+		})
+		idx = len(params) - 1
+	}
+	params[idx].GroupEstimates[row.Group] = estimate
+
+	return params
+}
+
+// hasEstimates reports whether the rows come from a fitted model. Rows without any p-values or confidence intervals
+// only carry fixed values
+func hasEstimates(rows []DataRow) bool {
+	for _, row := range rows {
+		if row.PValue != 0 || row.CiLower != 0 || row.CiUpper != 0 || row.Rhat != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isBayesian reports whether the rows summarise posterior distributions, as written for blavaan models
+func isBayesian(rows []DataRow) bool {
+	return slices.ContainsFunc(rows, func(row DataRow) bool { return row.Rhat != 0 || row.Median != 0 })
+}
+
+// ArrangeModel positions every node of the model from scratch with the given layout algorithm
+func ArrangeModel(m *model.Model, initialLayout InitialLayout) {
+	switch initialLayout {
+	case FORCE_DIRECTED:
+		forceDirectNodes(m)
+	default:
+		layeredLayout(m, initialLayout == LAYERED_VERTICAL)
+	}
+}
+
 func CalculateNodeNetwork(connections []*model.Connection) map[*model.Node][]*model.Node {
 	res := make(map[*model.Node][]*model.Node)
 	for _, c := range connections {
@@ -285,19 +528,65 @@ func forceDirectNodes(m *model.Model) {
 }
 
 func readJSON(path string) []DataRow {
+	rows, err := ReadRows(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return rows
+}
+
+// parameterTable is the form of the input that carries the fit indices of the model along with its parameters
+type parameterTable struct {
+	Parameters  []json.RawMessage `json:"parameters"`
+	FitMeasures model.FitMeasures `json:"fit_measures,omitempty"`
+}
+
+// ReadRows reads the parameter table written by the R package, either as an array of rows or as an object holding
+// them in "parameters"
+func ReadRows(path string) ([]DataRow, error) {
 	// read the json file
 	var rows []DataRow
 
 	data, err := os.ReadFile(path)
 	//data, err := os.ReadFile("test.json") // testing
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	err = json.Unmarshal(data, &rows)
+	// decode row by row so that a bad value can be traced to its row and column
+	var raw []json.RawMessage
+	if isJSONObject(data) {
+		var table parameterTable
+		err = json.Unmarshal(data, &table)
+		raw = table.Parameters
+	} else {
+		err = json.Unmarshal(data, &raw)
+	}
 	if err != nil {
-		log.Fatal(err)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+			return nil, fmt.Errorf("%s: line %d: %w", path, line, err)
+		}
+		return nil, fmt.Errorf("%s: expected an array of parameter rows: %w", path, err)
 	}
 
-	return rows
+	var errs []error
+	rows = make([]DataRow, len(raw))
+	for i, r := range raw {
+		err := json.Unmarshal(r, &rows[i])
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			errs = append(errs, fmt.Errorf("row %d, column %s: %s is not a %s", i+1, typeErr.Field, typeErr.Value, typeErr.Type))
+		case err != nil:
+			errs = append(errs, fmt.Errorf("row %d: %w", i+1, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s:\n%w", path, errors.Join(errs...))
+	}
+
+	return rows, nil
 }
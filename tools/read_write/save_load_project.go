@@ -20,6 +20,21 @@ func SaveProject(m *model.Model, path string) {
 	}
 }
 
+// IsProject tells a saved layout apart from the other JSON files of its directory, such as the parameter tables passed
+// to the editor
+func IsProject(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil || !isJSONObject(data) {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	_, ok := fields["nodes"]
+	return ok
+}
+
 func LoadProject(path string) (*model.Model, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -32,6 +47,20 @@ func LoadProject(path string) (*model.Model, error) {
 		panic(err)
 	}
 
+	// connections are saved with copies of their nodes, so point them back at the model's nodes
+	nodeMap := make(map[string]*model.Node)
+	for _, n := range m.Nodes {
+		nodeMap[n.VarName] = n
+	}
+	for _, c := range m.Connections {
+		if n, ok := nodeMap[c.Origin.VarName]; ok {
+			c.Origin = n
+		}
+		if n, ok := nodeMap[c.Destination.VarName]; ok {
+			c.Destination = n
+		}
+	}
+
 	// todo: consider why this needs to be here. How would I handle bold fonts?
 	switch m.Font.Family {
 	case "sans":
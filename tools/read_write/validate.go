@@ -0,0 +1,141 @@
+package read_write
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+type Severity int
+
+const (
+	SEVERITY_ERROR Severity = iota
+	SEVERITY_WARNING
+)
+
+// Diagnostic is a problem found in a parameter table
+type Diagnostic struct {
+	Row      int    // 1-based index of the row, 0 for problems with the table as a whole
+	Column   string // empty if the problem is not with a single column
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	severity := "error"
+	if d.Severity == SEVERITY_WARNING {
+		severity = "warning"
+	}
+
+	switch {
+	case d.Row == 0:
+		return fmt.Sprintf("%s: %s", severity, d.Message)
+	case d.Column == "":
+		return fmt.Sprintf("%s: row %d: %s", severity, d.Row, d.Message)
+	default:
+		return fmt.Sprintf("%s: row %d, column %s: %s", severity, d.Row, d.Column, d.Message)
+	}
+}
+
+// chains with a larger potential scale reduction factor are taken not to have converged
+const maxRhat = 1.1
+
+// operators that are drawn as nodes and connections
+var drawnOps = []string{"=~", "<~", "~", "~~", "~1"}
+
+// lavaan operators that are accepted but not drawn
+var otherOps = []string{"|", "~*~", ":=", "==", "<", ">", "r2"}
+
+// ValidateRows checks every row of a parameter table and returns the problems found, in row order
+func ValidateRows(rows []DataRow) []Diagnostic {
+	var diags []Diagnostic
+	add := func(row int, column string, severity Severity, format string, args ...any) {
+		diags = append(diags, Diagnostic{Row: row, Column: column, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// first row of each parameter, to report duplicates
+	seen := make(map[string]int)
+	drawn := 0
+
+	for i, row := range rows {
+		n := i + 1
+
+		switch {
+		case slices.Contains(drawnOps, row.Op):
+			drawn++
+		case slices.Contains(otherOps, row.Op):
+		case row.Op == "":
+			add(n, "op", SEVERITY_ERROR, "missing operator")
+		default:
+			// the row is skipped like the other operators that are not drawn, so the rest of the table still opens
+			add(n, "op", SEVERITY_WARNING, "unknown operator %q, the row is not drawn", row.Op)
+			continue
+		}
+
+		if row.Lhs == "" {
+			add(n, "lhs", SEVERITY_ERROR, "missing left-hand side")
+		}
+		// intercepts have no right-hand side, definitions imported from other programs may lack their expression, and the
+		// variance explained only concerns its left-hand side
+		if row.Rhs == "" && row.Op != "~1" && row.Op != ":=" && row.Op != "r2" {
+			add(n, "rhs", SEVERITY_ERROR, "missing right-hand side")
+		}
+
+		for _, v := range []struct {
+			column string
+			value  float64
+		}{{"est", row.Est}, {"pvalue", row.PValue}, {"ci_lower", row.CiLower}, {"ci_upper", row.CiUpper}, {"median", row.Median}, {"rhat", row.Rhat},
+			{"se", row.SE}, {"std_all", row.StdAll}, {"std_se", row.StdSE}, {"std_pvalue", row.StdPValue}, {"std_ci_lower", row.StdCiLower}, {"std_ci_upper", row.StdCiUpper}} {
+			if math.IsNaN(v.value) || math.IsInf(v.value, 0) {
+				add(n, v.column, SEVERITY_ERROR, "%v is not a valid value", v.value)
+			}
+		}
+
+		if row.PValue < 0 || row.PValue > 1 {
+			add(n, "pvalue", SEVERITY_ERROR, "p-value %v is outside of [0, 1]", row.PValue)
+		}
+		if row.StdPValue < 0 || row.StdPValue > 1 {
+			add(n, "std_pvalue", SEVERITY_ERROR, "p-value %v is outside of [0, 1]", row.StdPValue)
+		}
+		if row.SE < 0 {
+			add(n, "se", SEVERITY_ERROR, "standard error %v is negative", row.SE)
+		}
+		if row.StdSE < 0 {
+			add(n, "std_se", SEVERITY_ERROR, "standard error %v is negative", row.StdSE)
+		}
+
+		if row.Op == "r2" && (row.Est < 0 || row.Est > 1) {
+			add(n, "est", SEVERITY_WARNING, "R² of %v is outside of [0, 1]", row.Est)
+		}
+
+		if row.Rhat > maxRhat {
+			add(n, "rhat", SEVERITY_WARNING, "Rhat of %v is above %v, the chains may not have converged", row.Rhat, maxRhat)
+		}
+
+		switch {
+		case row.CiLower > row.CiUpper:
+			add(n, "ci_lower", SEVERITY_ERROR, "lower bound %v is above upper bound %v", row.CiLower, row.CiUpper)
+		case (row.CiLower != 0 || row.CiUpper != 0) && (row.Est < row.CiLower || row.Est > row.CiUpper):
+			add(n, "est", SEVERITY_WARNING, "estimate %v is outside of its interval [%v, %v]", row.Est, row.CiLower, row.CiUpper)
+		}
+
+		key := fmt.Sprintf("%s %s %s %d", row.Lhs, row.Op, row.Rhs, row.Group)
+		if first, ok := seen[key]; ok {
+			// the diagram can still be drawn, so this should not keep the editor from opening
+			add(n, "", SEVERITY_WARNING, "duplicate of row %d (%s %s %s in group %d), only the last one is drawn", first, row.Lhs, row.Op, row.Rhs, row.Group)
+		} else {
+			seen[key] = n
+		}
+	}
+
+	if drawn == 0 {
+		add(0, "", SEVERITY_ERROR, "the parameter table has no loadings, regressions, covariances or intercepts")
+	}
+
+	return diags
+}
+
+// HasErrors reports whether any of the diagnostics is an error rather than a warning
+func HasErrors(diags []Diagnostic) bool {
+	return slices.ContainsFunc(diags, func(d Diagnostic) bool { return d.Severity == SEVERITY_ERROR })
+}
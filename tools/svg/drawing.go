@@ -0,0 +1,187 @@
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"main/utils"
+	"math"
+	"strings"
+)
+
+func DrawRect(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	fmt.Fprintf(b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"`, pos.X, pos.Y, dim.W, dim.H, hexColor(col))
+
+	// Draw outline if thickness > 0
+	if thickness > 0 {
+		fmt.Fprintf(b, ` stroke="#000000" stroke-width="%.2f"`, thickness)
+	}
+	b.WriteString("/>\n")
+}
+
+func DrawEllipse(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Calculate center and radii
+	cx := pos.X + dim.W/2
+	cy := pos.Y + dim.H/2
+	rx := dim.W / 2
+	ry := dim.H / 2
+
+	fmt.Fprintf(b, `<ellipse cx="%.2f" cy="%.2f" rx="%.2f" ry="%.2f" fill="%s" stroke="#000000" stroke-width="%.2f"/>`+"\n",
+		cx, cy, rx, ry, hexColor(col), thickness)
+}
+
+func DrawTriangle(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Calculate vertices of an upward-pointing triangle from the top-left corner
+	v := utils.TriangleVertices(pos.AddDim(dim.Div(2)), dim)
+
+	fmt.Fprintf(b, `<polygon points="%.2f,%.2f %.2f,%.2f %.2f,%.2f" fill="%s" stroke="#000000" stroke-width="%.2f"/>`+"\n",
+		v[0].X, v[0].Y, v[1].X, v[1].Y, v[2].X, v[2].Y, hexColor(col), thickness)
+}
+
+func DrawHexagon(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	var points []string
+	for _, v := range utils.HexagonVertices(pos.AddDim(dim.Div(2)), dim) {
+		points = append(points, fmt.Sprintf("%.2f,%.2f", v.X, v.Y))
+	}
+
+	fmt.Fprintf(b, `<polygon points="%s" fill="%s" stroke="#000000" stroke-width="%.2f"/>`+"\n",
+		strings.Join(points, " "), hexColor(col), thickness)
+}
+
+func DrawArrowLine(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
+	angle := utils.GetAngleLoc(posA, posB)
+	arrowSize := thickness * 5
+
+	// Draw line shortened at posB to accommodate arrow
+	endPos := utils.MoveAlongAngleLoc(posB, angle+math.Pi, arrowSize*0.5)
+	DrawLine(b, posA, endPos, col, thickness)
+
+	// Draw arrow head at posB
+	DrawArrowHead(b, posB, angle, arrowSize, col)
+}
+
+func DrawArrowCurve(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32, curvature float32) {
+	// Calculate control point for quadratic bezier
+	ctrl := utils.GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
+
+	arrowSize := thickness * 5
+
+	// Angle at start: from posA toward control point
+	angleA := -math.Atan2(float64(ctrl.Y-posA.Y), float64(ctrl.X-posA.X)) + math.Pi
+
+	// Angle at end: from control point toward posB
+	angleB := -math.Atan2(float64(posB.Y-ctrl.Y), float64(posB.X-ctrl.X))
+
+	// Draw the curve shortened at both ends
+	startPos := utils.MoveAlongAngleLoc(posA, angleA+math.Pi, arrowSize*0.5)
+	endPos := utils.MoveAlongAngleLoc(posB, angleB+math.Pi, arrowSize*0.5)
+	DrawCurve(b, startPos, endPos, col, thickness, curvature)
+
+	// Draw arrow heads
+	DrawArrowHead(b, posA, angleA, arrowSize, col)
+	DrawArrowHead(b, posB, angleB, arrowSize, col)
+}
+
+func DrawLine(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
+	fmt.Fprintf(b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%.2f"/>`+"\n",
+		posA.X, posA.Y, posB.X, posB.Y, hexColor(col), thickness)
+}
+
+func DrawCurve(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32, curvature float32) {
+	ctrl := utils.GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
+
+	// SVG supports quadratic bezier curves natively, so no conversion to cubic is necessary
+	fmt.Fprintf(b, `<path d="M %.2f %.2f Q %.2f %.2f %.2f %.2f" fill="none" stroke="%s" stroke-width="%.2f"/>`+"\n",
+		posA.X, posA.Y, ctrl.X, ctrl.Y, posB.X, posB.Y, hexColor(col), thickness)
+}
+
+func DrawArrowHead(b *strings.Builder, basePos utils.LocalPos, angle float64, size float32, col color.NRGBA) {
+	// Calculate triangle points
+	p1 := utils.MoveAlongAngleLoc(basePos, angle+math.Pi+math.Pi/7.0, size)
+	p2 := utils.MoveAlongAngleLoc(basePos, angle+math.Pi-math.Pi/7.0, size)
+
+	fmt.Fprintf(b, `<polygon points="%.2f,%.2f %.2f,%.2f %.2f,%.2f" fill="%s"/>`+"\n",
+		p1.X, p1.Y, p2.X, p2.Y, basePos.X, basePos.Y, hexColor(col))
+}
+
+func DrawArc(b *strings.Builder, posA, posB, refPoint utils.LocalPos, radius float32, offsetAngle float64, col color.NRGBA, thickness float32) {
+	circleCenter := utils.FindCircleCenter(posA.ToF32(), posB.ToF32(), refPoint.ToF32(), radius)
+	angleA := utils.GetAngle(circleCenter, posA.ToF32())
+	angleB := utils.GetAngle(circleCenter, posB.ToF32())
+
+	offsetAngle *= 0.9 // shorten the offset angle a bit
+
+	startAngle := utils.NormalizeAngle(angleB - offsetAngle)
+	truncatedPosB := utils.MoveAlongAngle(circleCenter, startAngle, radius)
+
+	angleDiff := math.Mod(angleB-angleA+math.Pi, 2*math.Pi) - math.Pi
+	angle := utils.NormalizeAngle(angleDiff - 2*offsetAngle)
+
+	// the arc sweeps clockwise from the truncated end at posB
+	endPos := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(startAngle-angle), radius)
+
+	largeArc := 0
+	if angle > math.Pi {
+		largeArc = 1
+	}
+
+	fmt.Fprintf(b, `<path d="M %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f" fill="none" stroke="%s" stroke-width="%.2f" stroke-linecap="round"/>`+"\n",
+		truncatedPosB.X, truncatedPosB.Y, radius, radius, largeArc, endPos.X, endPos.Y, hexColor(col), thickness)
+}
+
+func DrawArrowArc(b *strings.Builder, posA, posB, refPoint utils.LocalPos, radius float32, col color.NRGBA, thickness float32) {
+	circleCenter := utils.FindCircleCenter(posA.ToF32(), posB.ToF32(), refPoint.ToF32(), radius)
+	arrowSize := float64(thickness * 5)
+	offsetAngle := arrowSize / float64(radius)
+	angleA := utils.GetAngle(circleCenter, posA.ToF32())
+	angleB := utils.GetAngle(circleCenter, posB.ToF32())
+	angleTangentA := utils.NormalizeAngle(angleA + offsetAngle - math.Pi/2)
+	angleTangentB := utils.NormalizeAngle(angleB - offsetAngle + math.Pi/2)
+
+	DrawArc(b, posA, posB, refPoint, radius, arrowSize/float64(radius), col, thickness)
+
+	truncatedPosA := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(angleA+offsetAngle), radius)
+	truncatedPosB := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(angleB-offsetAngle), radius)
+
+	arrowPosA := utils.MoveAlongAngle(truncatedPosA, angleTangentA, float32(arrowSize)-thickness)
+	arrowPosB := utils.MoveAlongAngle(truncatedPosB, angleTangentB, float32(arrowSize)-thickness)
+
+	DrawArrowHead(b, utils.ToLocalPos(arrowPosA), angleTangentA, float32(arrowSize), col)
+	DrawArrowHead(b, utils.ToLocalPos(arrowPosB), angleTangentB, float32(arrowSize), col)
+}
+
+// DrawText draws text centered on pos
+func DrawText(b *strings.Builder, pos utils.LocalPos, txt string, fontFamily string, bold bool, size float32) {
+	drawText(b, pos, txt, fontFamily, bold, size, "middle")
+}
+
+// DrawTextLeft draws text starting at pos, centered vertically
+func DrawTextLeft(b *strings.Builder, pos utils.LocalPos, txt string, fontFamily string, bold bool, size float32) {
+	drawText(b, pos, txt, fontFamily, bold, size, "start")
+}
+
+func drawText(b *strings.Builder, pos utils.LocalPos, txt string, fontFamily string, bold bool, size float32, anchor string) {
+	weight := "normal"
+	if bold {
+		weight = "bold"
+	}
+
+	fmt.Fprintf(b, `<text x="%.2f" y="%.2f" font-family="%s" font-weight="%s" font-size="%.2f" fill="#000000" text-anchor="%s" dominant-baseline="central">`,
+		pos.X, pos.Y, fontStack(fontFamily), weight, size, anchor)
+	xml.EscapeText(b, []byte(txt))
+	b.WriteString("</text>\n")
+}
+
+func hexColor(col color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", col.R, col.G, col.B)
+}
+
+// fontStack maps the model's font family onto the embedded Noto fonts with a generic fallback
+func fontStack(family string) string {
+	switch family {
+	case "serif":
+		return "'Noto Serif', serif"
+	default:
+		return "'Noto Sans', sans-serif"
+	}
+}
@@ -0,0 +1,190 @@
+package svg
+
+import (
+	"fmt"
+	"image/color"
+	"main/model"
+	"main/utils"
+	"os"
+	"strings"
+)
+
+const (
+	docPadding = 20
+	ppRatio    = .75 // pixel-to-point conversion, used to match the physical size of the PDF export
+)
+
+func ExportModel(m *model.Model, filePath string) {
+	// check for different PxPerDp. If not one, copy the model and apply a transformation
+	mAdj := model.PrepareForExport(m)
+
+	switch {
+	case !mAdj.IsMultiGroup():
+		writeFile(filePath, modelDocument(m, mAdj))
+	case mAdj.GroupPanels:
+		// place every group side by side in a single document
+		activeGroup := mAdj.ActiveGroup
+		titleHeight := m.Font.Size * 2
+		panels, docDim := model.LayoutPanels(mAdj, docPadding, titleHeight)
+
+		var b strings.Builder
+		writeHeader(&b, docDim)
+		for _, p := range panels {
+			model.ActivateGroupForExport(mAdj, p.Group)
+			DrawText(&b, utils.LocalPos{X: p.Pos.X + p.Dim.W/2, Y: p.Pos.Y + titleHeight/2}, p.Title, m.Font.Family, true, m.Font.Size)
+			drawModel(&b, m, mAdj, p.Offset)
+		}
+		b.WriteString("</svg>\n")
+		model.ActivateGroupForExport(mAdj, activeGroup)
+
+		writeFile(filePath, b.String())
+	default:
+		// one file per group
+		activeGroup := mAdj.ActiveGroup
+		for _, g := range mAdj.Groups {
+			model.ActivateGroupForExport(mAdj, g)
+			writeFile(model.GroupFilePath(filePath, g), modelDocument(m, mAdj))
+		}
+		model.ActivateGroupForExport(mAdj, activeGroup)
+	}
+}
+
+// modelDocument returns an SVG document sized to fit the model
+func modelDocument(m, mAdj *model.Model) string {
+	rect, localDim := model.GetModelSize(mAdj)
+
+	docDim := utils.LocalDim{W: localDim.W + 2*docPadding, H: localDim.H + 2*docPadding}
+
+	// Offset to translate model coordinates to document coordinates
+	offset := utils.LocalPos{X: docPadding - rect[0].X, Y: docPadding - rect[0].Y}
+
+	var b strings.Builder
+	writeHeader(&b, docDim)
+	drawModel(&b, m, mAdj, offset)
+	b.WriteString("</svg>\n")
+
+	return b.String()
+}
+
+func writeHeader(b *strings.Builder, docDim utils.LocalDim) {
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%.2fpt" height="%.2fpt" viewBox="0 0 %.2f %.2f">`+"\n",
+		docDim.W*ppRatio, docDim.H*ppRatio, docDim.W, docDim.H)
+}
+
+// drawModel draws the diagram translated by offset. m supplies the font settings of the original model
+func drawModel(b *strings.Builder, m, mAdj *model.Model, offset utils.LocalPos) {
+	for _, n := range mAdj.Nodes {
+		if !n.Visible {
+			continue
+		}
+
+		// Convert center position to top-left corner
+		adjPos := n.Pos.Add(offset).SubDim(n.Dim.Div(2))
+
+		switch n.Class {
+		case model.OBSERVED:
+			DrawRect(b, adjPos, n.Dim, n.Col, n.Thickness*.5) // .5 matches the outline weight of the PDF export
+		case model.LATENT:
+			DrawEllipse(b, adjPos, n.Dim, n.Col, n.Thickness*.5)
+		case model.INTERCEPT:
+			DrawTriangle(b, adjPos, n.Dim, n.Col, n.Thickness*.5)
+		case model.COMPOSITE:
+			DrawHexagon(b, adjPos, n.Dim, n.Col, n.Thickness*.5)
+		}
+
+		textPos := n.Pos.Add(offset)
+		if n.Class == model.INTERCEPT {
+			// center the text on the centroid of the triangle
+			textPos.Y += n.Dim.H / 6
+		}
+		textPos.Y += mAdj.NodeTextShift(n)
+		DrawText(b, textPos, n.Text, m.Font.Family, n.Bold, m.Font.Size)
+
+		if mAdj.ShowsThresholds(n) {
+			drawThresholds(b, m, mAdj, n, offset)
+		}
+	}
+
+	for _, c := range mAdj.Connections {
+		if !c.UserDefined && !mAdj.ViewGenerated {
+			continue
+		}
+
+		originPos := c.OriginPos.Add(offset)
+		destPos := c.DestinationPos.Add(offset)
+
+		switch c.Type {
+		case model.STRAIGHT:
+			DrawArrowLine(b, originPos, destPos, mAdj.ConnectionColor(c), c.Thickness)
+		case model.CURVED:
+			DrawArrowCurve(b, originPos, destPos, mAdj.ConnectionColor(c), c.Thickness, c.Curvature)
+		case model.CIRCULAR:
+			DrawArrowArc(b, originPos, destPos, c.RefPos.Add(offset), model.VarianceRadius, mAdj.ConnectionColor(c), c.Thickness)
+		}
+	}
+
+	// draw estimate labels after all the connections to ensure proper layering
+	for _, c := range mAdj.Connections {
+		if !mAdj.ShowsEstimate(c) {
+			continue
+		}
+
+		rectDim := c.EstDim.Div(m.PxPerDp)
+		rectPos := c.EstPos.Add(offset).SubDim(rectDim.Div(2))
+
+		DrawRect(b, rectPos, rectDim, color.NRGBA{255, 255, 255, 255}, 0)
+		DrawText(b, c.EstPos.Add(offset), c.EstText, m.Font.Family, false, m.Font.Size-2)
+	}
+
+	for _, n := range mAdj.Nodes {
+		if !mAdj.ShowsR2(n) {
+			continue
+		}
+
+		if mAdj.R2Display == model.R2_BADGE {
+			rect := mAdj.R2BadgeRect(n)
+			DrawRect(b, rect[0].Add(offset), utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}, color.NRGBA{255, 255, 255, 255}, model.R2BadgeThickness*.5)
+		}
+		txt, center := mAdj.R2Label(n)
+		DrawText(b, center.Add(offset), txt, m.Font.Family, false, mAdj.R2FontSize())
+	}
+
+	if mAdj.ShowsEffects() {
+		for _, l := range mAdj.EffectsRules() {
+			DrawLine(b, l[0].Add(offset), l[1].Add(offset), color.NRGBA{A: 255}, model.EffectsRuleThickness)
+		}
+		for _, t := range mAdj.EffectsTexts() {
+			DrawTextLeft(b, t.Pos.Add(offset), t.Text, m.Font.Family, false, mAdj.EffectsFontSize())
+		}
+	}
+
+	if mAdj.ShowsFit() {
+		rect := mAdj.FitRect()
+		dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+		DrawRect(b, rect[0].Add(offset), dim, color.NRGBA{255, 255, 255, 255}, model.FitBorderThickness*.5)
+		for _, t := range mAdj.FitTexts() {
+			DrawTextLeft(b, t.Pos.Add(offset), t.Text, m.Font.Family, false, mAdj.FitFontSize())
+		}
+	}
+}
+
+// drawThresholds draws the thresholds of an ordinal node below it, as tick marks or as a list
+func drawThresholds(b *strings.Builder, m, mAdj *model.Model, n *model.Node, offset utils.LocalPos) {
+	switch mAdj.ThresholdDisplay {
+	case model.THRESHOLDS_TICKS:
+		for _, l := range model.ThresholdTicks(n) {
+			DrawLine(b, l[0].Add(offset), l[1].Add(offset), color.NRGBA{A: 255}, model.ThresholdThickness)
+		}
+	case model.THRESHOLDS_LIST:
+		txt, center := mAdj.ThresholdLabel(n)
+		DrawText(b, center.Add(offset), txt, m.Font.Family, false, mAdj.ThresholdFontSize())
+	}
+}
+
+func writeFile(filePath, content string) {
+	err := os.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
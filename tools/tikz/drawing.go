@@ -0,0 +1,203 @@
+package tikz
+
+import (
+	"fmt"
+	"image/color"
+	"main/utils"
+	"math"
+	"strings"
+)
+
+// all coordinates are written in model units. The tikzpicture scales them to points and flips the y-axis
+
+func DrawRect(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Draw outline if thickness > 0
+	cmd := `\fill`
+	if thickness > 0 {
+		cmd = `\filldraw`
+	}
+	fmt.Fprintf(b, "%s[fill=%s, draw=black, line width=%.2fpt] (%.2f,%.2f) rectangle (%.2f,%.2f);\n",
+		cmd, tikzColor(col), thickness*ppRatio, pos.X, pos.Y, pos.X+dim.W, pos.Y+dim.H)
+}
+
+func DrawEllipse(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Calculate center and radii
+	cx := pos.X + dim.W/2
+	cy := pos.Y + dim.H/2
+	rx := dim.W / 2
+	ry := dim.H / 2
+
+	fmt.Fprintf(b, "\\filldraw[fill=%s, draw=black, line width=%.2fpt] (%.2f,%.2f) ellipse [x radius=%.2f, y radius=%.2f];\n",
+		tikzColor(col), thickness*ppRatio, cx, cy, rx, ry)
+}
+
+func DrawTriangle(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	// Calculate vertices of an upward-pointing triangle from the top-left corner
+	v := utils.TriangleVertices(pos.AddDim(dim.Div(2)), dim)
+
+	fmt.Fprintf(b, "\\filldraw[fill=%s, draw=black, line width=%.2fpt] (%.2f,%.2f) -- (%.2f,%.2f) -- (%.2f,%.2f) -- cycle;\n",
+		tikzColor(col), thickness*ppRatio, v[0].X, v[0].Y, v[1].X, v[1].Y, v[2].X, v[2].Y)
+}
+
+func DrawHexagon(b *strings.Builder, pos utils.LocalPos, dim utils.LocalDim, col color.NRGBA, thickness float32) {
+	var points []string
+	for _, v := range utils.HexagonVertices(pos.AddDim(dim.Div(2)), dim) {
+		points = append(points, fmt.Sprintf("(%.2f,%.2f)", v.X, v.Y))
+	}
+
+	fmt.Fprintf(b, "\\filldraw[fill=%s, draw=black, line width=%.2fpt] %s -- cycle;\n",
+		tikzColor(col), thickness*ppRatio, strings.Join(points, " -- "))
+}
+
+func DrawArrowLine(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
+	angle := utils.GetAngleLoc(posA, posB)
+	arrowSize := thickness * 5
+
+	// Draw line shortened at posB to accommodate arrow
+	endPos := utils.MoveAlongAngleLoc(posB, angle+math.Pi, arrowSize*0.5)
+	DrawLine(b, posA, endPos, col, thickness)
+
+	// Draw arrow head at posB
+	DrawArrowHead(b, posB, angle, arrowSize, col)
+}
+
+func DrawArrowCurve(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32, curvature float32) {
+	// Calculate control point for quadratic bezier
+	ctrl := utils.GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
+
+	arrowSize := thickness * 5
+
+	// Angle at start: from posA toward control point
+	angleA := -math.Atan2(float64(ctrl.Y-posA.Y), float64(ctrl.X-posA.X)) + math.Pi
+
+	// Angle at end: from control point toward posB
+	angleB := -math.Atan2(float64(posB.Y-ctrl.Y), float64(posB.X-ctrl.X))
+
+	// Draw the curve shortened at both ends
+	startPos := utils.MoveAlongAngleLoc(posA, angleA+math.Pi, arrowSize*0.5)
+	endPos := utils.MoveAlongAngleLoc(posB, angleB+math.Pi, arrowSize*0.5)
+	DrawCurve(b, startPos, endPos, col, thickness, curvature)
+
+	// Draw arrow heads
+	DrawArrowHead(b, posA, angleA, arrowSize, col)
+	DrawArrowHead(b, posB, angleB, arrowSize, col)
+}
+
+func DrawLine(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32) {
+	fmt.Fprintf(b, "\\draw[draw=%s, line width=%.2fpt] (%.2f,%.2f) -- (%.2f,%.2f);\n",
+		tikzColor(col), thickness*ppRatio, posA.X, posA.Y, posB.X, posB.Y)
+}
+
+func DrawCurve(b *strings.Builder, posA, posB utils.LocalPos, col color.NRGBA, thickness float32, curvature float32) {
+	ctrl := utils.GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
+
+	// TikZ curves are cubic, so we convert quadratic to cubic
+	// For quadratic P0, P1, P2: cubic control points are:
+	// C1 = P0 + 2/3*(P1-P0)
+	// C2 = P2 + 2/3*(P1-P2)
+	c1x := posA.X + (2.0/3.0)*(ctrl.X-posA.X)
+	c1y := posA.Y + (2.0/3.0)*(ctrl.Y-posA.Y)
+	c2x := posB.X + (2.0/3.0)*(ctrl.X-posB.X)
+	c2y := posB.Y + (2.0/3.0)*(ctrl.Y-posB.Y)
+
+	fmt.Fprintf(b, "\\draw[draw=%s, line width=%.2fpt] (%.2f,%.2f) .. controls (%.2f,%.2f) and (%.2f,%.2f) .. (%.2f,%.2f);\n",
+		tikzColor(col), thickness*ppRatio, posA.X, posA.Y, c1x, c1y, c2x, c2y, posB.X, posB.Y)
+}
+
+func DrawArrowHead(b *strings.Builder, basePos utils.LocalPos, angle float64, size float32, col color.NRGBA) {
+	// Calculate triangle points
+	p1 := utils.MoveAlongAngleLoc(basePos, angle+math.Pi+math.Pi/7.0, size)
+	p2 := utils.MoveAlongAngleLoc(basePos, angle+math.Pi-math.Pi/7.0, size)
+
+	fmt.Fprintf(b, "\\fill[fill=%s] (%.2f,%.2f) -- (%.2f,%.2f) -- (%.2f,%.2f) -- cycle;\n",
+		tikzColor(col), p1.X, p1.Y, p2.X, p2.Y, basePos.X, basePos.Y)
+}
+
+func DrawArc(b *strings.Builder, posA, posB, refPoint utils.LocalPos, radius float32, offsetAngle float64, col color.NRGBA, thickness float32) {
+	circleCenter := utils.FindCircleCenter(posA.ToF32(), posB.ToF32(), refPoint.ToF32(), radius)
+	angleA := utils.GetAngle(circleCenter, posA.ToF32())
+	angleB := utils.GetAngle(circleCenter, posB.ToF32())
+
+	offsetAngle *= 0.9 // shorten the offset angle a bit
+
+	startAngle := utils.NormalizeAngle(angleB - offsetAngle)
+	truncatedPosB := utils.MoveAlongAngle(circleCenter, startAngle, radius)
+
+	angleDiff := math.Mod(angleB-angleA+math.Pi, 2*math.Pi) - math.Pi
+	angle := utils.NormalizeAngle(angleDiff - 2*offsetAngle)
+
+	// angles are negated since the y-axis of the picture is flipped
+	startAngleDeg := -startAngle * 180 / math.Pi
+	endAngleDeg := startAngleDeg + angle*180/math.Pi
+
+	fmt.Fprintf(b, "\\draw[draw=%s, line width=%.2fpt, line cap=round] (%.2f,%.2f) arc[start angle=%.2f, end angle=%.2f, radius=%.2f];\n",
+		tikzColor(col), thickness*ppRatio, truncatedPosB.X, truncatedPosB.Y, startAngleDeg, endAngleDeg, radius)
+}
+
+func DrawArrowArc(b *strings.Builder, posA, posB, refPoint utils.LocalPos, radius float32, col color.NRGBA, thickness float32) {
+	circleCenter := utils.FindCircleCenter(posA.ToF32(), posB.ToF32(), refPoint.ToF32(), radius)
+	arrowSize := float64(thickness * 5)
+	offsetAngle := arrowSize / float64(radius)
+	angleA := utils.GetAngle(circleCenter, posA.ToF32())
+	angleB := utils.GetAngle(circleCenter, posB.ToF32())
+	angleTangentA := utils.NormalizeAngle(angleA + offsetAngle - math.Pi/2)
+	angleTangentB := utils.NormalizeAngle(angleB - offsetAngle + math.Pi/2)
+
+	DrawArc(b, posA, posB, refPoint, radius, arrowSize/float64(radius), col, thickness)
+
+	truncatedPosA := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(angleA+offsetAngle), radius)
+	truncatedPosB := utils.MoveAlongAngle(circleCenter, utils.NormalizeAngle(angleB-offsetAngle), radius)
+
+	arrowPosA := utils.MoveAlongAngle(truncatedPosA, angleTangentA, float32(arrowSize)-thickness)
+	arrowPosB := utils.MoveAlongAngle(truncatedPosB, angleTangentB, float32(arrowSize)-thickness)
+
+	DrawArrowHead(b, utils.ToLocalPos(arrowPosA), angleTangentA, float32(arrowSize), col)
+	DrawArrowHead(b, utils.ToLocalPos(arrowPosB), angleTangentB, float32(arrowSize), col)
+}
+
+// DrawText places text centered on pos. The font family is left to the enclosing document
+func DrawText(b *strings.Builder, pos utils.LocalPos, txt string, bold, background bool, size float32) {
+	opts := "inner sep=0pt, anchor=center"
+	if background {
+		opts = "fill=white, inner sep=1pt, anchor=center"
+	}
+	drawText(b, pos, txt, bold, opts, size)
+}
+
+// DrawTextLeft places text starting at pos, centered vertically
+func DrawTextLeft(b *strings.Builder, pos utils.LocalPos, txt string, bold bool, size float32) {
+	drawText(b, pos, txt, bold, "inner sep=0pt, anchor=west", size)
+}
+
+func drawText(b *strings.Builder, pos utils.LocalPos, txt string, bold bool, opts string, size float32) {
+	content := escapeLatex(txt)
+	if bold {
+		content = `\textbf{` + content + `}`
+	}
+
+	fmt.Fprintf(b, "\\node[%s] at (%.2f,%.2f) {\\fontsize{%.1f}{%.1f}\\selectfont %s};\n",
+		opts, pos.X, pos.Y, size*ppRatio, size*ppRatio*1.2, content)
+}
+
+func tikzColor(col color.NRGBA) string {
+	return fmt.Sprintf("{rgb,255:red,%d;green,%d;blue,%d}", col.R, col.G, col.B)
+}
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`^`, `\textasciicircum{}`,
+	`~`, `\textasciitilde{}`,
+	`χ`, `$\chi$`,
+	`²`, `\textsuperscript{2}`,
+)
+
+func escapeLatex(txt string) string {
+	return latexEscaper.Replace(txt)
+}
@@ -0,0 +1,187 @@
+package tikz
+
+import (
+	"fmt"
+	"image/color"
+	"main/model"
+	"main/utils"
+	"os"
+	"strings"
+)
+
+const (
+	ppRatio      = .75 // pixel-to-point conversion
+	panelPadding = 20
+)
+
+// ExportModel writes the model as a tikzpicture. If standalone is true, the picture is wrapped in a
+// compilable standalone document; otherwise the file can be \input into a manuscript.
+func ExportModel(m *model.Model, filePath string, standalone bool) {
+	// check for different PxPerDp. If not one, copy the model and apply a transformation
+	mAdj := model.PrepareForExport(m)
+
+	switch {
+	case !mAdj.IsMultiGroup():
+		var b strings.Builder
+		writeHeader(&b, standalone)
+		drawModel(&b, m, mAdj)
+		writeFooter(&b, standalone)
+
+		writeFile(filePath, b.String())
+	case mAdj.GroupPanels:
+		// place every group side by side in a single picture
+		activeGroup := mAdj.ActiveGroup
+		titleHeight := m.Font.Size * 2
+		panels, _ := model.LayoutPanels(mAdj, panelPadding, titleHeight)
+
+		var b strings.Builder
+		writeHeader(&b, standalone)
+		for _, p := range panels {
+			model.ActivateGroupForExport(mAdj, p.Group)
+			DrawText(&b, utils.LocalPos{X: p.Pos.X + p.Dim.W/2, Y: p.Pos.Y + titleHeight/2}, p.Title, true, false, m.Font.Size)
+
+			fmt.Fprintf(&b, "\\begin{scope}[shift={(%.2f,%.2f)}]\n", p.Offset.X, p.Offset.Y)
+			drawModel(&b, m, mAdj)
+			b.WriteString("\\end{scope}\n")
+		}
+		writeFooter(&b, standalone)
+		model.ActivateGroupForExport(mAdj, activeGroup)
+
+		writeFile(filePath, b.String())
+	default:
+		// one file per group
+		activeGroup := mAdj.ActiveGroup
+		for _, g := range mAdj.Groups {
+			model.ActivateGroupForExport(mAdj, g)
+
+			var b strings.Builder
+			writeHeader(&b, standalone)
+			drawModel(&b, m, mAdj)
+			writeFooter(&b, standalone)
+
+			writeFile(model.GroupFilePath(filePath, g), b.String())
+		}
+		model.ActivateGroupForExport(mAdj, activeGroup)
+	}
+}
+
+func writeHeader(b *strings.Builder, standalone bool) {
+	if standalone {
+		b.WriteString("\\documentclass[tikz]{standalone}\n")
+		b.WriteString("\\begin{document}\n")
+	}
+	// model units are pixels with y increasing downward
+	b.WriteString("\\begin{tikzpicture}[x=0.75pt, y=-0.75pt]\n")
+}
+
+func writeFooter(b *strings.Builder, standalone bool) {
+	b.WriteString("\\end{tikzpicture}\n")
+	if standalone {
+		b.WriteString("\\end{document}\n")
+	}
+}
+
+// drawModel draws the diagram in model coordinates. m supplies the font settings of the original model
+func drawModel(b *strings.Builder, m, mAdj *model.Model) {
+	for _, n := range mAdj.Nodes {
+		if !n.Visible {
+			continue
+		}
+
+		// Convert center position to top-left corner
+		adjPos := n.Pos.SubDim(n.Dim.Div(2))
+
+		switch n.Class {
+		case model.OBSERVED:
+			DrawRect(b, adjPos, n.Dim, n.Col, n.Thickness*.5) // .5 matches the outline weight of the PDF export
+		case model.LATENT:
+			DrawEllipse(b, adjPos, n.Dim, n.Col, n.Thickness*.5)
+		case model.INTERCEPT:
+			DrawTriangle(b, adjPos, n.Dim, n.Col, n.Thickness*.5)
+		case model.COMPOSITE:
+			DrawHexagon(b, adjPos, n.Dim, n.Col, n.Thickness*.5)
+		}
+
+		textPos := n.Pos
+		if n.Class == model.INTERCEPT {
+			// center the text on the centroid of the triangle
+			textPos.Y += n.Dim.H / 6
+		}
+		textPos.Y += mAdj.NodeTextShift(n)
+		DrawText(b, textPos, n.Text, n.Bold, false, m.Font.Size)
+
+		if mAdj.ShowsThresholds(n) {
+			switch mAdj.ThresholdDisplay {
+			case model.THRESHOLDS_TICKS:
+				for _, l := range model.ThresholdTicks(n) {
+					DrawLine(b, l[0], l[1], color.NRGBA{A: 255}, model.ThresholdThickness)
+				}
+			case model.THRESHOLDS_LIST:
+				txt, center := mAdj.ThresholdLabel(n)
+				DrawText(b, center, txt, false, false, mAdj.ThresholdFontSize())
+			}
+		}
+	}
+
+	for _, c := range mAdj.Connections {
+		if !c.UserDefined && !mAdj.ViewGenerated {
+			continue
+		}
+
+		switch c.Type {
+		case model.STRAIGHT:
+			DrawArrowLine(b, c.OriginPos, c.DestinationPos, mAdj.ConnectionColor(c), c.Thickness)
+		case model.CURVED:
+			DrawArrowCurve(b, c.OriginPos, c.DestinationPos, mAdj.ConnectionColor(c), c.Thickness, c.Curvature)
+		case model.CIRCULAR:
+			DrawArrowArc(b, c.OriginPos, c.DestinationPos, c.RefPos, model.VarianceRadius, mAdj.ConnectionColor(c), c.Thickness)
+		}
+	}
+
+	// draw estimate labels after all the connections to ensure proper layering
+	for _, c := range mAdj.Connections {
+		if !mAdj.ShowsEstimate(c) {
+			continue
+		}
+
+		DrawText(b, c.EstPos, c.EstText, false, true, m.Font.Size-2)
+	}
+
+	for _, n := range mAdj.Nodes {
+		if !mAdj.ShowsR2(n) {
+			continue
+		}
+
+		if mAdj.R2Display == model.R2_BADGE {
+			rect := mAdj.R2BadgeRect(n)
+			DrawRect(b, rect[0], utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}, color.NRGBA{255, 255, 255, 255}, model.R2BadgeThickness*.5)
+		}
+		txt, center := mAdj.R2Label(n)
+		DrawText(b, center, txt, false, false, mAdj.R2FontSize())
+	}
+
+	if mAdj.ShowsEffects() {
+		for _, l := range mAdj.EffectsRules() {
+			DrawLine(b, l[0], l[1], color.NRGBA{A: 255}, model.EffectsRuleThickness)
+		}
+		for _, t := range mAdj.EffectsTexts() {
+			DrawTextLeft(b, t.Pos, t.Text, false, mAdj.EffectsFontSize())
+		}
+	}
+
+	if mAdj.ShowsFit() {
+		rect := mAdj.FitRect()
+		dim := utils.LocalDim{W: rect[1].X - rect[0].X, H: rect[1].Y - rect[0].Y}
+		DrawRect(b, rect[0], dim, color.NRGBA{255, 255, 255, 255}, model.FitBorderThickness*.5)
+		for _, t := range mAdj.FitTexts() {
+			DrawTextLeft(b, t.Pos, t.Text, false, mAdj.FitFontSize())
+		}
+	}
+}
+
+func writeFile(filePath, content string) {
+	err := os.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
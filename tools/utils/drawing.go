@@ -1,11 +1,9 @@
 package utils
 
 import (
-	"fmt"
 	"image"
 	"image/color"
 	"math"
-	"strconv"
 	"strings"
 
 	"gioui.org/f32"
@@ -29,6 +27,10 @@ const (
 	VALUE
 	INTERVAL
 	STAR
+	POSTERIOR_MEAN     // the posterior mean, marked when the credible interval excludes zero
+	POSTERIOR_MEDIAN   // the posterior median, marked when the credible interval excludes zero
+	POSTERIOR_INTERVAL // the posterior mean with its credible interval
+	POSTERIOR_RHAT     // the posterior mean with the potential scale reduction factor of its chains
 )
 
 func MakeRect(pos GlobalPos, dim GlobalDim) image.Rectangle {
@@ -64,6 +66,22 @@ func DrawRect(ops *op.Ops, pos GlobalPos, dim GlobalDim, col color.NRGBA, thickn
 	}
 }
 
+// DrawSelectionRect fills a rectangle with a translucent color and outlines it in the opaque version of that color
+func DrawSelectionRect(ops *op.Ops, rect image.Rectangle, col color.NRGBA, thickness float32) {
+	r := clip.Rect(rect.Canon())
+
+	paint.FillShape(ops, col, r.Op())
+
+	outlineCol := col
+	outlineCol.A = 255
+	paint.FillShape(ops, outlineCol,
+		clip.Stroke{
+			Path:  r.Path(),
+			Width: thickness,
+		}.Op(),
+	)
+}
+
 func DrawRoundedRect(ops *op.Ops, pos GlobalPos, dim GlobalDim, r int, col color.NRGBA, thickness float32) {
 	rrect := clip.RRect{
 		Rect: MakeRect(pos, dim),
@@ -106,6 +124,55 @@ func DrawEllipse(ops *op.Ops, pos GlobalPos, dim GlobalDim, col color.NRGBA, thi
 	)
 }
 
+func DrawHexagon(ops *op.Ops, pos GlobalPos, dim GlobalDim, col color.NRGBA, thickness float32) {
+	rect := MakeRect(pos, dim)
+	center := LocalPos{X: float32(rect.Min.X+rect.Max.X) / 2, Y: float32(rect.Min.Y+rect.Max.Y) / 2}
+	vertices := HexagonVertices(center, LocalDim{W: float32(rect.Dx()), H: float32(rect.Dy())})
+
+	var path clip.Path
+	path.Begin(ops)
+	path.MoveTo(vertices[0].ToF32())
+	for _, v := range vertices[1:] {
+		path.LineTo(v.ToF32())
+	}
+	path.Close()
+	spec := path.End()
+
+	// Draw fill
+	paint.FillShape(ops, col, clip.Outline{Path: spec}.Op())
+
+	// Draw outline
+	paint.FillShape(ops, color.NRGBA{R: 0, G: 0, B: 0, A: 255},
+		clip.Stroke{
+			Path:  spec,
+			Width: thickness,
+		}.Op(),
+	)
+}
+
+func DrawTriangle(ops *op.Ops, pos GlobalPos, dim GlobalDim, col color.NRGBA, thickness float32) {
+	rect := MakeRect(pos, dim)
+
+	var path clip.Path
+	path.Begin(ops)
+	path.MoveTo(f32.Pt(float32(rect.Min.X+rect.Max.X)/2, float32(rect.Min.Y)))
+	path.LineTo(f32.Pt(float32(rect.Max.X), float32(rect.Max.Y)))
+	path.LineTo(f32.Pt(float32(rect.Min.X), float32(rect.Max.Y)))
+	path.Close()
+	spec := path.End()
+
+	// Draw fill
+	paint.FillShape(ops, col, clip.Outline{Path: spec}.Op())
+
+	// Draw outline
+	paint.FillShape(ops, color.NRGBA{R: 0, G: 0, B: 0, A: 255},
+		clip.Stroke{
+			Path:  spec,
+			Width: thickness,
+		}.Op(),
+	)
+}
+
 func DrawArrowCurve(ops *op.Ops, posA, posB GlobalPos, col color.NRGBA, thickness, curvature float32, windowSize GlobalDim) {
 	// Calculate control point for tangent angles
 	ctrl := GetCtrlPoint(posA.ToF32(), posB.ToF32(), curvature)
@@ -293,33 +360,57 @@ func DrawEstimate(ops *op.Ops, gtx layout.Context, pos GlobalPos, fontStyle font
 	DrawText(ops, gtx, pos.SubDim(textOffset.ToGlobal(scaleFactor)), estText, fontStyle, unit.Sp(fontSize-2), scaleFactor)
 }
 
-func CalculateEstimate(fontStyle font.FontFace, fontSize float32, displayStyle CoefficientDisplay, est, pVal float64, ci [2]float64, precision int, padding float32, gtx layout.Context) (string, LocalDim, float32) {
-	// define the string to be printed
-	var estText string
+// SignificanceStars marks p-values below .05, .01 and .001 with one, two and three stars
+func SignificanceStars(pVal float64) string {
+	switch {
+	case pVal < .001:
+		return "***"
+	case pVal < .01:
+		return "**"
+	case pVal < .05:
+		return "*"
+	}
+	return ""
+}
 
-	floatFmtStr := "%." + strconv.Itoa(precision) + "f"
-	switch displayStyle {
+// ExcludesZeroMarker takes the place of the significance stars for posterior estimates
+func ExcludesZeroMarker(ci [2]float64) string {
+	if ci[0] > 0 || ci[1] < 0 {
+		return "*"
+	}
+	return ""
+}
+
+func (d CoefficientDisplay) String() string {
+	switch d {
 	case VALUE:
-		estText = fmt.Sprintf(floatFmtStr, est)
+		return "values"
 	case INTERVAL:
-		estText = fmt.Sprintf(floatFmtStr+floatFmtStr, ci[0], ci[1])
+		return "intervals"
 	case STAR:
-		var stars string
-		switch {
-		case pVal < .001:
-			stars = "***"
-		case pVal < .01:
-			stars = "**"
-		case pVal < .05:
-			stars = "*"
-		}
-		estText = fmt.Sprintf(floatFmtStr+"%s", est, stars)
+		return "values with significance stars"
+	case POSTERIOR_MEAN:
+		return "posterior means, marked when the interval excludes zero"
+	case POSTERIOR_MEDIAN:
+		return "posterior medians, marked when the interval excludes zero"
+	case POSTERIOR_INTERVAL:
+		return "posterior means with credible intervals"
+	case POSTERIOR_RHAT:
+		return "posterior means with Rhat"
 	default:
+		return "hidden"
 	}
+}
+
+// IsPosterior reports whether the display style shows the summary of a posterior distribution
+func (d CoefficientDisplay) IsPosterior() bool {
+	return d >= POSTERIOR_MEAN
+}
 
-	// draw the background rectangle
+// MeasureEstimate returns the size of the background rectangle of an estimate label and the width of its text
+func MeasureEstimate(estText string, fontStyle font.FontFace, fontSize float32, padding float32, gtx layout.Context) (LocalDim, float32) {
 	textWidth := GetTextWidth(estText, fontStyle, fontSize, gtx)
 	adjWidth := textWidth + padding*3.0
 	height := fontSize * 1.5 // todo: find a better way to determine height of text
-	return estText, LocalDim{W: adjWidth, H: height}, textWidth
+	return LocalDim{W: adjWidth, H: height}, textWidth
 }
@@ -59,3 +59,56 @@ func SegmentIntersectsRect(a, b LocalPos, r LocalRect) bool {
 
 	return false
 }
+
+// TriangleVertices returns the vertices of an upward-pointing triangle inscribed in the bounding box
+func TriangleVertices(pos LocalPos, dim LocalDim) [3]LocalPos {
+	return [3]LocalPos{
+		{X: pos.X, Y: pos.Y - dim.H/2},
+		{X: pos.X + dim.W/2, Y: pos.Y + dim.H/2},
+		{X: pos.X - dim.W/2, Y: pos.Y + dim.H/2},
+	}
+}
+
+// HexagonVertices returns the vertices of a hexagon inscribed in the bounding box, with its corners pointing left and
+// right. The slanted edges take up a quarter of the height on each side
+func HexagonVertices(pos LocalPos, dim LocalDim) [6]LocalPos {
+	inset := dim.H / 4
+	return [6]LocalPos{
+		{X: pos.X - dim.W/2, Y: pos.Y},
+		{X: pos.X - dim.W/2 + inset, Y: pos.Y - dim.H/2},
+		{X: pos.X + dim.W/2 - inset, Y: pos.Y - dim.H/2},
+		{X: pos.X + dim.W/2, Y: pos.Y},
+		{X: pos.X + dim.W/2 - inset, Y: pos.Y + dim.H/2},
+		{X: pos.X - dim.W/2 + inset, Y: pos.Y + dim.H/2},
+	}
+}
+
+// RayPolygonIntersection returns the point where a ray cast from a point inside a polygon crosses its boundary
+func RayPolygonIntersection(origin LocalPos, angle float64, poly []LocalPos) LocalPos {
+	dx := float32(math.Cos(angle))
+	dy := -float32(math.Sin(angle))
+
+	best := float32(math.MaxFloat32)
+	for i := range poly {
+		a := poly[i]
+		b := poly[(i+1)%len(poly)]
+
+		// solve origin + t*d = a + s*(b-a)
+		ex := b.X - a.X
+		ey := b.Y - a.Y
+		denom := dx*ey - dy*ex
+		if denom == 0 {
+			continue
+		}
+		t := ((a.X-origin.X)*ey - (a.Y-origin.Y)*ex) / denom
+		s := ((a.X-origin.X)*dy - (a.Y-origin.Y)*dx) / denom
+		if t >= 0 && s >= 0 && s <= 1 && t < best {
+			best = t
+		}
+	}
+
+	if best == math.MaxFloat32 {
+		return origin
+	}
+	return LocalPos{X: origin.X + best*dx, Y: origin.Y + best*dy}
+}
@@ -6,6 +6,7 @@ import (
 	"gioui.org/font/opentype"
 	"gioui.org/text"
 	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/sfnt"
 )
 
 //go:embed fonts/Noto_Sans/static/NotoSans-Medium.ttf
@@ -38,6 +39,18 @@ func LoadSerifFontFace() []text.FontFace {
 	return []text.FontFace{normalFontFace, boldFontFace}
 }
 
+// LoadRasterFonts returns the normal and bold fonts of a family for use with the software rasterizer
+func LoadRasterFonts(family string) []*sfnt.Font {
+	normalData, boldData := sansNormalData, sansBoldData
+	if family == "serif" {
+		normalData, boldData = serifNormalData, serifBoldData
+	}
+
+	normal, _ := sfnt.Parse(normalData)
+	bold, _ := sfnt.Parse(boldData)
+	return []*sfnt.Font{normal, bold}
+}
+
 func LoadPdfFonts(pdf *gofpdf.Fpdf) {
 	// Add sans regular
 	pdf.AddFont("sans", "", "NotoSans-Regular.json")
@@ -26,6 +26,41 @@ func WithinEllipse(pos image.Point, rect image.Rectangle) bool {
 	return dx*dx+dy*dy <= 1.0
 }
 
+func WithinTriangle(pos image.Point, rect image.Rectangle) bool {
+	// upward-pointing triangle inscribed in the rectangle
+	a := f32.Point{X: float32(rect.Min.X+rect.Max.X) / 2, Y: float32(rect.Min.Y)}
+	b := f32.Point{X: float32(rect.Max.X), Y: float32(rect.Max.Y)}
+	c := f32.Point{X: float32(rect.Min.X), Y: float32(rect.Max.Y)}
+	p := f32.Point{X: float32(pos.X), Y: float32(pos.Y)}
+
+	// the point is inside if it is on the same side of every edge
+	side := func(u, v f32.Point) float32 {
+		return (v.X-u.X)*(p.Y-u.Y) - (v.Y-u.Y)*(p.X-u.X)
+	}
+	d1, d2, d3 := side(a, b), side(b, c), side(c, a)
+
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+func WithinHexagon(pos image.Point, rect image.Rectangle) bool {
+	center := LocalPos{X: float32(rect.Min.X+rect.Max.X) / 2, Y: float32(rect.Min.Y+rect.Max.Y) / 2}
+	dim := LocalDim{W: float32(rect.Dx()), H: float32(rect.Dy())}
+	vertices := HexagonVertices(center, dim)
+	p := LocalPos{X: float32(pos.X), Y: float32(pos.Y)}
+
+	// the hexagon is convex, so the point is inside if it is on the same side of every edge
+	for i := range vertices {
+		u := vertices[i]
+		v := vertices[(i+1)%len(vertices)]
+		if (v.X-u.X)*(p.Y-u.Y)-(v.Y-u.Y)*(p.X-u.X) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func WithinLine(pos image.Point, a, b GlobalPos, tolerance float32) bool {
 	p := f32.Point{X: float32(pos.X), Y: float32(pos.Y)}
 	closest, _ := ProjectOntoLine(a.ToF32(), b.ToF32(), p)